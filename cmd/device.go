@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/initializer"
+	"github.com/quorumcontrol/dgit/msg"
+	"github.com/quorumcontrol/dgit/transport/dgit"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+var deviceName string
+
+func init() {
+	deviceCommand.Flags().StringVar(&deviceName, "name", "", "name of this machine when pairing it (defaults to the hostname)")
+	rootCmd.AddCommand(deviceCommand)
+}
+
+var deviceCommand = &cobra.Command{
+	Use:   "device (add [pairing code] | list | revoke [name])",
+	Short: "Authorize additional machines to use your decentragit user",
+	Long: `Run "device add" on a new machine to generate its key and a pairing code.
+Then run "device add [pairing code]" on a machine that is already authorized to add it to your user.
+Revoked devices lose access without changing your recovery phrase.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+
+		switch args[0] {
+		case "add":
+			return nil
+		case "list":
+			if len(args) != 1 {
+				return fmt.Errorf("list command does not take any arguments")
+			}
+			return nil
+		case "revoke":
+			if len(args) != 2 {
+				return fmt.Errorf("revoke command requires a single device name")
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown arguments to device command: %v", args)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		switch args[0] {
+		case "add":
+			if len(args) == 1 {
+				pairDevice(ctx, client, repo)
+			} else {
+				addDevice(ctx, client, repo, strings.Join(args[1:], ""))
+			}
+		case "list":
+			devices, err := client.ListDevices(ctx, repo)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			for _, device := range devices {
+				fmt.Printf("%s (%s)\n", device.Name, device.Address)
+			}
+		case "revoke":
+			confirmed, err := initializer.Confirm(msg.Parse(msg.PromptDeviceRevoke, map[string]interface{}{
+				"device": args[1],
+			}), os.Stdin, os.Stdout)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if !confirmed {
+				os.Exit(1)
+			}
+
+			device, err := client.RevokeDevice(ctx, repo, args[1])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Revoked %s (%s)\n", device.Name, device.Address)
+		}
+	},
+}
+
+func pairDevice(ctx context.Context, client *dgit.Client, repo *dgit.Repo) {
+	name := deviceName
+	if name == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "could not determine hostname, use --name to name this machine")
+			os.Exit(1)
+		}
+		name = hostname
+	}
+
+	code, err := client.NewDevicePairingCode(ctx, repo, name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.DevicePairingCode, map[string]interface{}{
+		"username": code.Username,
+		"device":   code.Device,
+		"code":     code.String(),
+	})
+	fmt.Println()
+}
+
+func addDevice(ctx context.Context, client *dgit.Client, repo *dgit.Repo, codeStr string) {
+	code, err := usertree.ParsePairingCode(codeStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	confirmed, err := initializer.Confirm(msg.Parse(msg.PromptDeviceAdd, map[string]interface{}{
+		"username": code.Username,
+		"device":   code.Device,
+		"address":  code.Address,
+	}), os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !confirmed {
+		os.Exit(1)
+	}
+
+	err = client.AddDevice(ctx, repo, code)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.DeviceAdded, map[string]interface{}{
+		"username": code.Username,
+		"device":   code.Device,
+	})
+	fmt.Println()
+}
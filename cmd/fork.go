@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/msg"
+	"github.com/quorumcontrol/dgit/transport/dgit"
+)
+
+func init() {
+	rootCmd.AddCommand(forkCommand)
+}
+
+var forkCommand = &cobra.Command{
+	Use:   "fork [user/repo]",
+	Short: "Fork a decentragit repo into your user",
+	Long: `Creates [your-username]/[repo] as a fork of [user/repo].
+The fork starts with all refs of the parent and shares its stored objects.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		parent := strings.ToLower(strings.TrimPrefix(args[0], dgit.Protocol()+"://"))
+
+		fork, err := client.ForkRepo(ctx, repo, parent)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		username, err := repo.Username()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		msg.Print(msg.RepoForked, map[string]interface{}{
+			"parent":  parent,
+			"repourl": dgit.Protocol() + "://" + username + "/" + fork.Name(),
+			"did":     fork.Did(),
+		})
+		fmt.Println()
+	},
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/initializer"
+	"github.com/quorumcontrol/dgit/msg"
+	"github.com/quorumcontrol/dgit/transport/dgit"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+var guardianThreshold int
+
+func init() {
+	guardianCommand.Flags().IntVarP(&guardianThreshold, "threshold", "t", 0, "number of guardians needed to recover your user (defaults to a majority)")
+	rootCmd.AddCommand(guardianCommand)
+}
+
+var guardianCommand = &cobra.Command{
+	Use:   "guardian (enable | set [usernames] | list | approve [recovery code])",
+	Short: "Choose users who can together recover your decentragit user",
+	Long: `Guardians can recover your user if you lose your recovery phrase and every device.
+Guardians first run "guardian enable". Then "guardian set" splits a new recovery key between them,
+any --threshold of which can approve a "git dg recover" of your user.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+
+		switch args[0] {
+		case "enable", "list":
+			if len(args) != 1 {
+				return fmt.Errorf("%s command does not take any arguments", args[0])
+			}
+			return nil
+		case "set":
+			if len(args) < 2 {
+				return fmt.Errorf("set command requires at least one guardian username")
+			}
+			return nil
+		case "approve":
+			if len(args) < 2 {
+				return fmt.Errorf("approve command requires a recovery code")
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown arguments to guardian command: %v", args)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		switch args[0] {
+		case "enable":
+			err = client.EnableGuardian(ctx, repo)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			msg.Print(msg.GuardianEnabled, nil)
+			fmt.Println()
+		case "set":
+			setGuardians(ctx, client, repo, args[1:])
+		case "list":
+			guardians, err := client.ListGuardians(ctx, repo)
+			if err == usertree.ErrNoGuardians {
+				fmt.Println("No guardians set, add some with `git dg guardian set [usernames]`")
+				return
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("%d of these guardians can recover your user:\n", guardians.Threshold)
+			for _, guardian := range guardians.Guardians {
+				fmt.Printf("  %s\n", guardian.Username)
+			}
+		case "approve":
+			approveRecovery(ctx, client, repo, strings.Join(args[1:], ""))
+		}
+	},
+}
+
+func setGuardians(ctx context.Context, client *dgit.Client, repo *dgit.Repo, usernames []string) {
+	threshold := guardianThreshold
+	if threshold == 0 {
+		threshold = len(usernames)/2 + 1
+	}
+
+	err := client.SetGuardians(ctx, repo, usernames, threshold)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.GuardiansSet, map[string]interface{}{
+		"guardians": strings.Join(usernames, ", "),
+		"threshold": threshold,
+	})
+	fmt.Println()
+}
+
+func approveRecovery(ctx context.Context, client *dgit.Client, repo *dgit.Repo, codeStr string) {
+	code, err := usertree.ParseRecoveryCode(codeStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	confirmed, err := initializer.Confirm(msg.Parse(msg.PromptRecoveryApprove, map[string]interface{}{
+		"username": code.Username,
+		"address":  code.Address(),
+	}), os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !confirmed {
+		os.Exit(1)
+	}
+
+	err = client.ApproveRecovery(ctx, repo, code)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.RecoveryApproved, map[string]interface{}{
+		"username": code.Username,
+	})
+	fmt.Println()
+}
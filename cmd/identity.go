@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/keyring"
+	"github.com/quorumcontrol/dgit/transport/dgit"
+)
+
+func init() {
+	rootCmd.AddCommand(identityCommand)
+	identityCommand.Flags().StringVarP(&identityRemote, "remote", "r", "", "use the identity only for this remote")
+	identityCommand.Flags().StringVarP(&identityURLPattern, "url", "u", "", "use the identity only for repo urls matching this pattern, e.g. dg://my-org/*")
+}
+
+var (
+	identityRemote     string
+	identityURLPattern string
+)
+
+var identityCommand = &cobra.Command{
+	Use:   "identity (list | use [username])",
+	Short: "Manage which decentragit user is used in the current repo",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+
+		switch args[0] {
+		case "list":
+			if len(args) != 1 {
+				return fmt.Errorf("%s command does not take any arguments", args[0])
+			}
+			return nil
+		case "use":
+			if len(args) != 2 {
+				return fmt.Errorf("%s command requires a username", args[0])
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown arguments to identity command: %v", args)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		switch args[0] {
+		case "list":
+			listIdentities(repo)
+		case "use":
+			err = repo.UseIdentity(args[1], identityRemote, identityURLPattern)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Printf("Using identity %s\n", args[1])
+		}
+	},
+}
+
+func listIdentities(repo *dgit.Repo) {
+	kr, err := keyring.NewDefault()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	identities, err := dgit.Identities(kr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// the repo may not be configured yet, only mark the current identity
+	// when there is one
+	var current string
+	if identity, err := repo.Identity(); err == nil {
+		current = identity.Username
+	}
+
+	for _, username := range identities {
+		if username == current {
+			fmt.Printf("* %s\n", username)
+		} else {
+			fmt.Printf("  %s\n", username)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/initializer"
+	"github.com/quorumcontrol/dgit/msg"
+	"github.com/quorumcontrol/dgit/tupelo/teamtree"
+)
+
+func init() {
+	rootCmd.AddCommand(invitesCommand)
+}
+
+var invitesCommand = &cobra.Command{
+	Use:   "invites (list | accept [invite code] | confirm)",
+	Short: "Manage invitations to repo teams",
+	Long: `Collaborators added with "git dg team add" are invited rather than added right away.
+They accept with the code they were sent, which signs the invitation with their own
+key, and only get access once a repo admin confirms.
+
+list shows the pending invitations to your repo's teams and which were accepted.
+confirm adds the collaborators which accepted to their teams.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+
+		switch args[0] {
+		case "list", "confirm":
+			if len(args) != 1 {
+				return fmt.Errorf("unexpected arguments after %s command", args[0])
+			}
+			return nil
+		case "accept":
+			if len(args) < 2 {
+				return fmt.Errorf("accept command requires an invite code")
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown arguments to invites command: %v", args)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		switch args[0] {
+		case "list":
+			invitations, err := client.ListRepoInvitations(ctx, repo)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			for _, invitation := range invitations {
+				status := ""
+				if invitation.Accepted {
+					status = ", accepted"
+				}
+				fmt.Printf("%s to %s team, invited %s%s\n", invitation.Username, invitation.Team, invitation.CreatedAt.Format("2006-01-02"), status)
+			}
+		case "confirm":
+			confirmed, err := client.ConfirmRepoInvitations(ctx, repo)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			if len(confirmed) == 0 {
+				fmt.Println("No invitations have been accepted")
+				return
+			}
+			for _, invitation := range confirmed {
+				fmt.Printf("Added %s to the %s team\n", invitation.Username, invitation.Team)
+			}
+		case "accept":
+			// codes may be pasted with spaces between their groups
+			code, err := teamtree.ParseInviteCode(strings.Join(args[1:], ""))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			team, err := client.InvitedTeam(ctx, code)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			confirmed, err := initializer.Confirm(msg.Parse(msg.PromptInviteAccept, map[string]interface{}{
+				"team":     team.Name(),
+				"username": code.Username,
+			}), os.Stdin, os.Stdout)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if !confirmed {
+				os.Exit(1)
+			}
+
+			err = client.AcceptInvite(ctx, repo, code)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			msg.Print(msg.InviteAccepted, map[string]interface{}{
+				"team": team.Name(),
+			})
+			fmt.Println()
+		}
+	},
+}
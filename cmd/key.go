@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/initializer"
+	"github.com/quorumcontrol/dgit/keyring"
+	"github.com/quorumcontrol/dgit/msg"
+	"github.com/quorumcontrol/dgit/transport/dgit"
+)
+
+func init() {
+	rootCmd.AddCommand(keyCommand)
+	keyCommand.Flags().BoolVarP(&keyImportForce, "force", "f", false, "replace a different key already in the keyring on import")
+}
+
+var keyImportForce bool
+
+var keyCommand = &cobra.Command{
+	Use:   "key (rotate | export [file] | import [file])",
+	Short: "Manage the key of your decentragit user",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+
+		switch args[0] {
+		case "rotate":
+			if len(args) != 1 {
+				return fmt.Errorf("%s command does not take any arguments", args[0])
+			}
+			return nil
+		case "export", "import":
+			if len(args) != 2 {
+				return fmt.Errorf("%s command requires a key file", args[0])
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown arguments to key command: %v", args)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		switch args[0] {
+		case "rotate":
+			rotateKey(ctx, client, repo)
+		case "export":
+			exportKey(ctx, client, repo, args[1])
+		case "import":
+			importKey(ctx, client, args[1])
+		}
+	},
+}
+
+func rotateKey(ctx context.Context, client *dgit.Client, repo *dgit.Repo) {
+	rotation, err := client.NewKeyRotation(ctx, repo)
+	if errors.Is(err, dgit.ErrPreviousRotationCompleted) {
+		fmt.Println(err)
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.KeyRotationSeedPhrase, map[string]interface{}{
+		"username": rotation.Username,
+		"seed":     initializer.FormatSeedPhrase(rotation.Mnemonic),
+	})
+	fmt.Println()
+
+	confirmed, err := initializer.Confirm(msg.PromptKeyRotation, os.Stdin, os.Stdout)
+	if err != nil || !confirmed {
+		rotation.Abort()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+
+	err = rotation.Commit(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.KeyRotated, map[string]interface{}{
+		"username": rotation.Username,
+	})
+	fmt.Println()
+}
+
+func exportKey(ctx context.Context, client *dgit.Client, repo *dgit.Repo, path string) {
+	passphrase, err := initializer.Passphrase(msg.PromptKeyExportPassphrase, true, os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	keyFile, err := client.ExportKey(ctx, repo, passphrase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	if err = keyFile.Write(f); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.KeyExported, map[string]interface{}{
+		"username": keyFile.Username,
+		"path":     path,
+	})
+	fmt.Println()
+}
+
+func importKey(ctx context.Context, client *dgit.Client, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	keyFile, err := keyring.ReadKeyFile(f)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	passphrase, err := initializer.Passphrase(msg.PromptKeyImportPassphrase, false, os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = client.ImportKey(ctx, keyFile, passphrase, keyImportForce)
+	if errors.Is(err, dgit.ErrKeyExists) {
+		fmt.Fprintf(os.Stderr, "%v, use --force to replace it\n", err)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.KeyImported, map[string]interface{}{
+		"username": keyFile.Username,
+	})
+	fmt.Println()
+}
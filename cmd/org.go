@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(orgCommand)
+}
+
+var orgCommand = &cobra.Command{
+	Use:   "org (create [org] | add-member [org] [usernames] | list [org])",
+	Short: "Manage decentragit organizations",
+	Long: `Organizations are a namespace for repos owned by a team of users rather than a single user.
+Repos pushed to dg://[org]/[repo] are administered by every org member.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+
+		switch args[0] {
+		case "create", "list":
+			if len(args) != 2 {
+				return fmt.Errorf("%s command requires a single org name", args[0])
+			}
+			return nil
+		case "add-member":
+			if len(args) < 3 {
+				return fmt.Errorf("add-member command requires an org name and one or more usernames")
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown arguments to org command: %v", args)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		orgName := strings.ToLower(args[1])
+
+		switch args[0] {
+		case "create":
+			org, err := client.CreateOrg(ctx, repo, orgName)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Created org %s (%s)\n", org.Name(), org.Did())
+		case "add-member":
+			err := client.AddOrgMembers(ctx, repo, orgName, args[2:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Added to %s:\n%s\n", orgName, strings.Join(args[2:], "\n"))
+		case "list":
+			members, err := client.ListOrgMembers(ctx, orgName)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Members of %s:\n%s\n", orgName, strings.Join(members, "\n"))
+		}
+	},
+}
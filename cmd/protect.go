@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
+)
+
+var (
+	protectAllowForcePush bool
+	protectAllowDelete    bool
+	protectPushers        []string
+	protectTeams          []string
+)
+
+func init() {
+	protectCommand.Flags().BoolVar(&protectAllowForcePush, "allow-force-push", false, "allow non-fast-forward updates of matching refs")
+	protectCommand.Flags().BoolVar(&protectAllowDelete, "allow-delete", false, "allow deleting matching refs")
+	protectCommand.Flags().StringSliceVar(&protectPushers, "pushers", nil, "usernames allowed to push updates of matching refs")
+	protectCommand.Flags().StringSliceVar(&protectTeams, "teams", nil, "repo teams allowed to update matching refs")
+	rootCmd.AddCommand(protectCommand)
+}
+
+var protectCommand = &cobra.Command{
+	Use:   "protect (add [ref pattern] | list | remove [ref pattern])",
+	Short: "Manage protected branches of your repo",
+	Long: `Protection rules are stored in the repo and checked before any matching ref is updated.
+Patterns are branch names like "main", or full ref globs like "refs/heads/release/*".
+By default protected refs can neither be force pushed nor deleted.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+
+		switch args[0] {
+		case "add", "remove":
+			if len(args) != 2 {
+				return fmt.Errorf("%s command requires a single ref pattern", args[0])
+			}
+			return nil
+		case "list":
+			if len(args) != 1 {
+				return fmt.Errorf("unexpected arguments after list command")
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown arguments to protect command: %v", args)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		switch args[0] {
+		case "add":
+			rule := &repotree.ProtectionRule{
+				Pattern:        repotree.NormalizeRefPattern(args[1]),
+				NoForcePush:    !protectAllowForcePush,
+				NoDelete:       !protectAllowDelete,
+				AllowedPushers: protectPushers,
+				AllowedTeams:   protectTeams,
+			}
+
+			err := client.ProtectRef(ctx, repo, rule)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Protected %s\n", rule.Pattern)
+		case "list":
+			rules, err := client.ListProtectionRules(ctx, repo)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			for _, rule := range rules {
+				fmt.Println(describeProtectionRule(rule))
+			}
+		case "remove":
+			pattern := repotree.NormalizeRefPattern(args[1])
+			err := client.UnprotectRef(ctx, repo, pattern)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Removed protection from %s\n", pattern)
+		}
+	},
+}
+
+func describeProtectionRule(rule *repotree.ProtectionRule) string {
+	restrictions := []string{}
+	if rule.NoForcePush {
+		restrictions = append(restrictions, "no force push")
+	}
+	if rule.NoDelete {
+		restrictions = append(restrictions, "no delete")
+	}
+	if len(rule.AllowedPushers) > 0 {
+		restrictions = append(restrictions, "pushers: "+strings.Join(rule.AllowedPushers, ", "))
+	}
+	if len(rule.AllowedTeams) > 0 {
+		restrictions = append(restrictions, "teams: "+strings.Join(rule.AllowedTeams, ", "))
+	}
+	return fmt.Sprintf("%s (%s)", rule.Pattern, strings.Join(restrictions, "; "))
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/msg"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+func init() {
+	rootCmd.AddCommand(recoverCommand)
+}
+
+var recoverCommand = &cobra.Command{
+	Use:   "recover",
+	Short: "Recover your decentragit user with the help of your guardians",
+	Long: `Prints a recovery code for your guardians to approve with "git dg guardian approve".
+Run it again once enough guardians have approved to move your user to this machine's new key.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		recovery, err := client.NewRecovery(ctx, repo)
+		if err == usertree.ErrNoGuardians {
+			fmt.Fprintln(os.Stderr, "your user has no guardians, it can only be recovered with its recovery phrase by running `git dg init`")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		if recovery.Approvals < recovery.Threshold {
+			msg.Print(msg.RecoveryCode, map[string]interface{}{
+				"username":  recovery.Code.Username,
+				"code":      recovery.Code.String(),
+				"approvals": recovery.Approvals,
+				"threshold": recovery.Threshold,
+			})
+			fmt.Println()
+			return
+		}
+
+		err = client.CompleteRecovery(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		msg.Print(msg.UserRecovered, map[string]interface{}{
+			"username": recovery.Code.Username,
+		})
+		fmt.Println()
+	},
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
+)
+
+var reflogMaxCount int
+
+func init() {
+	reflogCommand.Flags().IntVarP(&reflogMaxCount, "max-count", "n", 0, "only list the given number of most recent changes")
+	reflogCommand.AddCommand(reflogRestoreCommand)
+	rootCmd.AddCommand(reflogCommand)
+}
+
+var reflogCommand = &cobra.Command{
+	Use:   "reflog [ref]",
+	Short: "Show or restore previous values of a ref of your repo",
+	Long: `Every ref update is a notarized block of the repo's ChainTree, so its full history
+can be read back: reflog lists each value a ref held, newest first, with when it was set
+and the addresses of the keys that signed the update. Changes made without a push, such
+as by a fork or a move, have no time.
+
+Use "reflog restore" to point the ref back at one of its entries.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		name := plumbing.ReferenceName(repotree.NormalizeRefPattern(args[0]))
+
+		changes, err := client.RefHistory(ctx, repo, name, reflogMaxCount)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		for i, change := range changes {
+			fmt.Println(describeRefChange(name, i, change))
+		}
+	},
+}
+
+var reflogRestoreCommand = &cobra.Command{
+	Use:   "restore [ref] [entry]",
+	Short: "Point a ref back at a previous value",
+	Long: `restore points the ref back at an entry of its reflog, given either as its number
+from the listing (e.g. "main@{2}" or "2") or its commit hash. Restoring is an update
+like a push, and needs write access to the repo.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		name := plumbing.ReferenceName(repotree.NormalizeRefPattern(args[0]))
+
+		changes, err := client.RefHistory(ctx, repo, name, 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		hash, err := reflogEntry(name, changes, args[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		err = client.RestoreRef(ctx, repo, name, hash)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Restored %s to %s\n", name, hash)
+	},
+}
+
+func describeRefChange(name plumbing.ReferenceName, i int, change *repotree.RefChange) string {
+	value := change.Hash.String()[:7]
+	if change.IsDelete() {
+		value = "deleted"
+	}
+
+	signers := "unsigned"
+	if len(change.Signers) > 0 {
+		signers = "signed by " + strings.Join(change.Signers, ", ")
+	}
+
+	when := "unknown time"
+	if !change.Timestamp.IsZero() {
+		when = change.Timestamp.Format("2006-01-02 15:04:05 -0700")
+	}
+
+	return fmt.Sprintf("%s %s@{%d}: %s, %s", value, name.Short(), i, when, signers)
+}
+
+// reflogEntry finds the hash of a history entry given by its number or by
+// a commit hash prefix
+func reflogEntry(name plumbing.ReferenceName, changes []*repotree.RefChange, entry string) (plumbing.Hash, error) {
+	if i := strings.Index(entry, "@{"); i >= 0 && strings.HasSuffix(entry, "}") {
+		entry = entry[i+2 : len(entry)-1]
+	}
+
+	var change *repotree.RefChange
+	if n, err := strconv.Atoi(entry); err == nil && len(entry) < 7 {
+		if n < 0 || n >= len(changes) {
+			return plumbing.ZeroHash, fmt.Errorf("%s has %d reflog entries, there is no entry %d", name.Short(), len(changes), n)
+		}
+		change = changes[n]
+	} else {
+		for _, c := range changes {
+			if !c.IsDelete() && strings.HasPrefix(c.Hash.String(), strings.ToLower(entry)) {
+				change = c
+				break
+			}
+		}
+		if change == nil {
+			return plumbing.ZeroHash, fmt.Errorf("%s never pointed at %s", name.Short(), entry)
+		}
+	}
+
+	if change.IsDelete() {
+		return plumbing.ZeroHash, fmt.Errorf("entry %s of %s is a deletion, choose an entry with a commit to restore", entry, name.Short())
+	}
+
+	return change.Hash, nil
+}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/initializer"
+	"github.com/quorumcontrol/dgit/msg"
+	"github.com/quorumcontrol/dgit/transport/dgit"
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
+)
+
+var (
+	repoDescription string
+	repoTopics      string
+	repoHomepage    string
+	repoLicense     string
+	repoReadme      string
+	repoListJSON    bool
+)
+
+func init() {
+	repoCommand.Flags().StringVar(&repoDescription, "description", "", "short description of the repo when editing")
+	repoCommand.Flags().StringVar(&repoTopics, "topics", "", "comma separated topics of the repo when editing")
+	repoCommand.Flags().StringVar(&repoHomepage, "homepage", "", "homepage url of the repo when editing")
+	repoCommand.Flags().StringVar(&repoLicense, "license", "", "SPDX license id of the repo when editing, e.g. MIT")
+	repoCommand.Flags().StringVar(&repoReadme, "readme", "", "path of the readme file in the repo when editing")
+	repoCommand.Flags().BoolVar(&repoListJSON, "json", false, "output the repo list as json")
+	rootCmd.AddCommand(repoCommand)
+}
+
+var repoCommand = &cobra.Command{
+	Use:   "repo (list [user] | info | edit | rename [new-name] | move [user or org you manage] | archive | delete)",
+	Short: "Manage the decentragit repo of the current directory",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+
+		switch args[0] {
+		case "rename", "move":
+			if len(args) != 2 {
+				return fmt.Errorf("%s command requires a single argument", args[0])
+			}
+			return nil
+		case "list":
+			if len(args) > 2 {
+				return fmt.Errorf("list command takes at most one user or org name")
+			}
+			return nil
+		case "info", "edit", "archive", "delete":
+			if len(args) != 1 {
+				return fmt.Errorf("%s command does not take any arguments", args[0])
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown arguments to repo command: %v", args)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		switch args[0] {
+		case "rename", "move":
+			moveRepo(ctx, client, repo, args[0], strings.ToLower(args[1]))
+		case "list":
+			listRepos(ctx, client, repo, args[1:])
+		case "info":
+			showRepoInfo(ctx, client, repo)
+		case "edit":
+			editRepo(ctx, cmd, client, repo)
+		case "archive":
+			archiveRepo(ctx, client, repo)
+		case "delete":
+			deleteRepo(ctx, client, repo)
+		}
+	},
+}
+
+func moveRepo(ctx context.Context, client *dgit.Client, repo *dgit.Repo, subCmd string, arg string) {
+	from, err := repo.Name()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	oldURL := repo.MustURL()
+
+	fromParts := strings.SplitN(from, "/", 2)
+	to := fromParts[0] + "/" + arg
+	if subCmd == "move" {
+		to = arg + "/" + fromParts[1]
+	}
+
+	_, err = client.MoveRepo(ctx, repo, to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.RepoMoved, map[string]interface{}{
+		"from": from,
+		"to":   to,
+	})
+	fmt.Println()
+
+	newURL := dgit.Protocol() + "://" + to
+	remotes, err := initializer.UpdateRemoteURLs(repo, oldURL, newURL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if len(remotes) > 0 {
+		msg.Print(msg.RemotesUpdated, map[string]interface{}{
+			"remotes": strings.Join(remotes, ", "),
+			"repourl": newURL,
+		})
+		fmt.Println()
+	}
+}
+
+func archiveRepo(ctx context.Context, client *dgit.Client, repo *dgit.Repo) {
+	repoName, err := repo.Name()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	confirmRepoCommand(msg.PromptRepoArchive, repoName)
+
+	err = client.ArchiveRepo(ctx, repo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.RepoArchived, map[string]interface{}{
+		"repo": repoName,
+	})
+	fmt.Println()
+}
+
+func deleteRepo(ctx context.Context, client *dgit.Client, repo *dgit.Repo) {
+	repoName, err := repo.Name()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	confirmRepoCommand(msg.PromptRepoDelete, repoName)
+
+	err = client.DeleteRepo(ctx, repo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	msg.Print(msg.RepoDeleted, map[string]interface{}{
+		"repo": repoName,
+	})
+	fmt.Println()
+}
+
+// confirmRepoCommand exits unless the user confirms the prompt
+func confirmRepoCommand(prompt string, repoName string) {
+	confirmed, err := initializer.Confirm(msg.Parse(prompt, map[string]interface{}{
+		"repo": repoName,
+	}), os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !confirmed {
+		os.Exit(1)
+	}
+}
+
+func showRepoInfo(ctx context.Context, client *dgit.Client, repo *dgit.Repo) {
+	repoName, err := repo.Name()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	repoTree, err := client.FindRepoTree(ctx, repoName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	metadata, err := repoTree.Metadata(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	archived, err := repoTree.Archived(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	parent, err := repoTree.Parent(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s (%s)\n", repoName, repoTree.Did())
+	if archived {
+		fmt.Println("archived:    true")
+	}
+	if parent != nil {
+		fmt.Printf("forked from: %s\n", parent.Did())
+	}
+	fmt.Printf("description: %s\n", metadata.Description)
+	fmt.Printf("topics:      %s\n", strings.Join(metadata.Topics, ", "))
+	fmt.Printf("homepage:    %s\n", metadata.Homepage)
+	fmt.Printf("license:     %s\n", metadata.License)
+	fmt.Printf("readme:      %s\n", metadata.Readme)
+}
+
+func editRepo(ctx context.Context, cmd *cobra.Command, client *dgit.Client, repo *dgit.Repo) {
+	repoName, err := repo.Name()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	repoTree, err := client.FindRepoTree(ctx, repoName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	metadata, err := repoTree.Metadata(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// only the given flags are changed, so a flag set to "" clears its field
+	flags := cmd.Flags()
+	if flags.Changed("description") {
+		metadata.Description = repoDescription
+	}
+	if flags.Changed("topics") {
+		metadata.Topics = repotree.ParseTopics(repoTopics)
+	}
+	if flags.Changed("homepage") {
+		metadata.Homepage = repoHomepage
+	}
+	if flags.Changed("license") {
+		metadata.License = repoLicense
+	}
+	if flags.Changed("readme") {
+		metadata.Readme = repoReadme
+	}
+
+	err = client.SetRepoMetadata(ctx, repo, metadata)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	showRepoInfo(ctx, client, repo)
+}
+
+func listRepos(ctx context.Context, client *dgit.Client, repo *dgit.Repo, args []string) {
+	var ownerName string
+	if len(args) > 0 {
+		ownerName = strings.ToLower(args[0])
+	} else {
+		username, err := repo.Username()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		ownerName = username
+	}
+
+	summaries, err := client.ListRepos(ctx, ownerName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if repoListJSON {
+		out, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDEFAULT BRANCH\tSTORAGE\tUPDATED\tTEAM SIZE")
+	for _, summary := range summaries {
+		name := summary.Name
+		if summary.Archived {
+			name += " (archived)"
+		}
+
+		updatedAt := "-"
+		if !summary.UpdatedAt.IsZero() {
+			updatedAt = summary.UpdatedAt.Local().Format(time.RFC3339)
+		}
+
+		defaultBranch := summary.DefaultBranch
+		if defaultBranch == "" {
+			defaultBranch = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", name, defaultBranch, summary.ObjectStorage, updatedAt, summary.TeamSize)
+	}
+	w.Flush()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/storage/filesystem"
@@ -44,3 +45,20 @@ func newClient(ctx context.Context, repo *dgit.Repo) (*dgit.Client, error) {
 
 	return client, nil
 }
+
+// newSharedClient starts a client whose node store is kept under
+// ~/.decentragit rather than in a repo, for commands serving several repos
+func newSharedClient(ctx context.Context) (*dgit.Client, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("error finding home directory: %w", err)
+	}
+
+	client, err := dgit.NewClient(ctx, filepath.Join(home, ".decentragit"))
+	if err != nil {
+		return nil, fmt.Errorf("error starting decentragit client: %w", err)
+	}
+	client.RegisterAsDefault()
+
+	return client, nil
+}
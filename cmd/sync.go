@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/mirror"
+)
+
+var (
+	syncOnce       bool
+	syncStatusFile string
+)
+
+func init() {
+	syncCommand.Flags().BoolVar(&syncOnce, "once", false, "sync each repo once and exit, failing if any sync failed")
+	syncCommand.Flags().StringVar(&syncStatusFile, "status-file", defaultSyncStatusFile(), "file the sync status of each repo is written to as JSON")
+	rootCmd.AddCommand(syncCommand)
+}
+
+var syncCommand = &cobra.Command{
+	Use:   "sync [repo paths]",
+	Short: "Keep dg remotes in sync with an upstream remote like GitHub",
+	Long: `sync fetches from each repo's upstream remote on a schedule and pushes the branches
+and tags which changed to its dg remote, until interrupted. It syncs the repo in the
+current directory when no paths are given.
+
+Pushes go through the same checks as git push, so the repo's roles and protection
+rules apply. A repo whose sync fails is retried after twice the wait of the last
+attempt, up to 6 hours.
+
+Each repo is configured in its git config:
+
+  decentragit.sync.upstream   the remote to fetch from, origin by default
+  decentragit.sync.remote     the dg remote to push to, found from its url by default
+  decentragit.sync.interval   how often to sync, like 5m or 1h, 15m by default
+  decentragit.sync.force      push upstream force pushes and deletions of branches and
+                              tags, false by default`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			cancel()
+		}()
+
+		paths := args
+		if len(paths) == 0 {
+			callingDir, err := os.Getwd()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+				os.Exit(1)
+			}
+			paths = []string{callingDir}
+		}
+
+		mirrors := make([]*mirror.Mirror, len(paths))
+		for i, path := range paths {
+			m, err := mirror.Open(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			mirrors[i] = m
+		}
+
+		// one client serves every repo, it is registered for the dg
+		// protocol used by the pushes
+		_, err := newSharedClient(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		daemon := mirror.NewDaemon(mirrors, syncStatusFile, os.Stdout)
+
+		if syncOnce {
+			if err := daemon.SyncOnce(ctx); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		for _, m := range mirrors {
+			fmt.Printf("Syncing %s from %s to %s every %s\n", m.Path, m.Config.Upstream, m.Config.Remote, m.Config.Interval)
+		}
+
+		err = daemon.Run(ctx)
+		if err != nil && err != context.Canceled {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func defaultSyncStatusFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".decentragit", "sync-status.json")
+}
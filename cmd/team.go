@@ -7,15 +7,41 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/initializer"
+	"github.com/quorumcontrol/dgit/msg"
+	"github.com/quorumcontrol/dgit/transport/dgit"
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
+	"github.com/quorumcontrol/dgit/tupelo/teamtree"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+var (
+	teamName   string
+	teamRole   string
+	teamYes    bool
+	teamDryRun bool
 )
 
 func init() {
+	teamCommand.Flags().StringVar(&teamName, "team", repotree.DefaultTeamName, "name of the repo team to manage")
+	teamCommand.Flags().StringVar(&teamRole, "role", "", "role of a team created by add: read, write or admin (new teams default to write)")
+	teamCommand.Flags().BoolVarP(&teamYes, "yes", "y", false, "invite collaborators without confirming their profiles")
+	teamCommand.Flags().BoolVar(&teamDryRun, "dry-run", false, "show the team remove would leave behind without changing it")
 	rootCmd.AddCommand(teamCommand)
 }
 
 var teamCommand = &cobra.Command{
-	Use:   "team (add [usernames] | list | remove [usernames])",
-	Short: "Manage your repo's team of collaborators",
+	Use:   "team (add [usernames or @teams] | list | remove [usernames or @teams] | set-role [role])",
+	Short: "Manage your repo's teams of collaborators",
+	Long: `Users added to a team are invited, and get access once they accept.
+
+Teams can also include other teams, written as @org for an org's members or
+@owner/repo:team for a team of another repo. Their members get access right away,
+and keep it in step with the included team.
+
+--role only applies to a team created by add, use set-role to change the role of an
+existing team.`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
 			return cmd.Help()
@@ -32,6 +58,11 @@ var teamCommand = &cobra.Command{
 				return fmt.Errorf("unexpected arguments after list command")
 			}
 			return nil
+		case "set-role":
+			if len(args) != 2 {
+				return fmt.Errorf("set-role command requires a single role")
+			}
+			return nil
 		default:
 			return fmt.Errorf("unknown arguments to team command: %v", args)
 		}
@@ -58,29 +89,197 @@ var teamCommand = &cobra.Command{
 
 		switch subCmd {
 		case "add":
-			err := client.AddRepoCollaborator(ctx, repo, args[1:])
+			role := repotree.RoleNone
+			if teamRole != "" {
+				role, err = repotree.ParseRole(teamRole)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			}
+
+			if !teamYes {
+				confirmCollaborators(ctx, client, args[1:])
+			}
+
+			codes, err := client.AddRepoCollaborator(ctx, repo, teamName, role, args[1:])
 			if err != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 
-			fmt.Printf("Added:\n%s\n", strings.Join(args[1:], "\n"))
+			for _, collaborator := range args[1:] {
+				if dgit.IsTeamRef(collaborator) {
+					fmt.Printf("Included %s in the %s team\n", collaborator, teamName)
+				}
+			}
+
+			for _, code := range codes {
+				msg.Print(msg.TeamInvited, map[string]interface{}{
+					"username": code.Username,
+					"team":     teamName,
+					"code":     code.String(),
+				})
+			}
+			fmt.Println()
 		case "list":
-			collaborators, err := client.ListRepoCollaborators(ctx, repo)
+			teams, err := client.ListRepoTeams(ctx, repo)
 			if err != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 
-			fmt.Printf("Current collaborators:\n%s\n", strings.Join(collaborators, "\n"))
+			for _, team := range teams {
+				members, err := team.Tree.ListMembers(ctx)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+
+				invitations, err := team.Tree.Invitations(ctx)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+
+				memberTeams, err := team.Tree.ListMemberTeams(ctx)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+
+				names := members.Names()
+				for _, memberTeam := range memberTeams {
+					names = append(names, memberTeam.Name()+" (team)")
+				}
+				for _, invitation := range invitations {
+					names = append(names, invitation.Username+" (invited)")
+				}
+
+				fmt.Printf("%s team (%s):\n%s\n", team.Name, team.Role, strings.Join(names, "\n"))
+			}
 		case "remove":
-			err := client.RemoveRepoCollaborator(ctx, repo, args[1:])
+			if teamDryRun {
+				removal, err := client.PlanRemoveRepoCollaborator(ctx, repo, teamName, args[1:])
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+
+				printRemoval(removal)
+				return
+			}
+
+			_, err := client.RemoveRepoCollaborator(ctx, repo, teamName, args[1:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Removed collaborators from %s team:\n%s\n", teamName, strings.Join(args[1:], "\n"))
+		case "set-role":
+			role, err := repotree.ParseRole(args[1])
 			if err != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 
-			fmt.Printf("Removed collaborators:\n%s\n", strings.Join(args[1:], "\n"))
+			err = client.SetRepoTeamRole(ctx, repo, teamName, role)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("The %s team now has the %s role\n", teamName, role)
 		}
 	},
 }
+
+// printRemoval previews a team removal, including the owners of the team
+// chaintree it would leave behind
+func printRemoval(removal *teamtree.Removal) {
+	for _, member := range removal.Removed {
+		fmt.Printf("Would remove %s\n", member.Name())
+	}
+	for _, team := range removal.RemovedTeams {
+		fmt.Printf("Would remove the %s team\n", team.Name())
+	}
+	for _, invitation := range removal.Revoked {
+		fmt.Printf("Would revoke the invitation of %s\n", invitation.Username)
+	}
+
+	memberNames := removal.Members.Names()
+	for _, team := range removal.Teams {
+		memberNames = append(memberNames, team.Name()+" (team)")
+	}
+	fmt.Printf("\n%s team members after removal:\n%s\n", teamName, strings.Join(memberNames, "\n"))
+
+	names := make(map[string]string)
+	for _, member := range append(removal.Members, removal.Teams...) {
+		names[member.Did()] = member.Name()
+	}
+
+	fmt.Printf("\n%s team owners after removal:\n", teamName)
+	for _, owner := range removal.Owners {
+		if name, ok := names[owner]; ok {
+			fmt.Printf("%s (%s)\n", owner, name)
+		} else {
+			fmt.Println(owner)
+		}
+	}
+}
+
+// confirmCollaborators shows the profile of each user to be added, so a
+// mistyped or lookalike username is caught before it is granted access
+func confirmCollaborators(ctx context.Context, client *dgit.Client, usernames []string) {
+	for _, username := range usernames {
+		if dgit.IsTeamRef(username) {
+			confirmTeamRef(ctx, client, username)
+			continue
+		}
+
+		profile, err := client.UserProfile(ctx, username)
+		if err == usertree.ErrNotFound {
+			msg.Fprint(os.Stderr, msg.UserNotFound, map[string]interface{}{
+				"user": username,
+			})
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		printProfile(username, profile)
+		fmt.Println()
+	}
+
+	confirmed, err := initializer.Confirm(msg.Parse(msg.PromptTeamAdd, map[string]interface{}{
+		"usernames": strings.Join(usernames, ", "),
+		"team":      teamName,
+	}), os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !confirmed {
+		os.Exit(1)
+	}
+}
+
+// confirmTeamRef shows who would get access through an included team
+func confirmTeamRef(ctx context.Context, client *dgit.Client, ref string) {
+	team, err := client.FindTeamRef(ctx, ref)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	members, err := team.ExpandMembers(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s (%s)\nmembers: %s\n\n", ref, team.Did(), strings.Join(members.Names(), ", "))
+}
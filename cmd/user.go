@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/msg"
+	"github.com/quorumcontrol/dgit/transport/dgit"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+var (
+	userDisplayName string
+	userEmail       string
+	userAvatar      string
+	userWebsite     string
+	userGPGKeys     []string
+	userSSHKeys     []string
+)
+
+func init() {
+	userCommand.Flags().StringVar(&userDisplayName, "display-name", "", "your name as shown to others when editing")
+	userCommand.Flags().StringVar(&userEmail, "email", "", "public email address when editing")
+	userCommand.Flags().StringVar(&userAvatar, "avatar", "", "link to an avatar image when editing")
+	userCommand.Flags().StringVar(&userWebsite, "website", "", "website url when editing")
+	userCommand.Flags().StringArrayVar(&userGPGKeys, "gpg-key", nil, "file with an armored GPG public key when editing, repeat for several keys")
+	userCommand.Flags().StringArrayVar(&userSSHKeys, "ssh-key", nil, "file with an SSH public key when editing, repeat for several keys")
+	rootCmd.AddCommand(userCommand)
+}
+
+var userCommand = &cobra.Command{
+	Use:   "user (show [username] | edit)",
+	Short: "Show and edit decentragit user profiles",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+
+		switch args[0] {
+		case "show":
+			if len(args) > 2 {
+				return fmt.Errorf("show command takes at most one username")
+			}
+			return nil
+		case "edit":
+			if len(args) != 1 {
+				return fmt.Errorf("edit command does not take any arguments")
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown arguments to user command: %v", args)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		switch args[0] {
+		case "show":
+			var username string
+			if len(args) > 1 {
+				username = strings.ToLower(args[1])
+			} else {
+				username, err = repo.Username()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			}
+			showUser(ctx, client, username)
+		case "edit":
+			editUser(ctx, cmd, client, repo)
+		}
+	},
+}
+
+func showUser(ctx context.Context, client *dgit.Client, username string) {
+	profile, err := client.UserProfile(ctx, username)
+	if err == usertree.ErrNotFound {
+		msg.Fprint(os.Stderr, msg.UserNotFound, map[string]interface{}{
+			"user": username,
+		})
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	printProfile(username, profile)
+}
+
+func printProfile(username string, profile *usertree.Profile) {
+	fmt.Println(username)
+	fmt.Printf("name:     %s\n", profile.DisplayName)
+	fmt.Printf("email:    %s\n", profile.Email)
+	fmt.Printf("avatar:   %s\n", profile.Avatar)
+	fmt.Printf("website:  %s\n", profile.Website)
+	fmt.Printf("gpg keys: %s\n", strings.Join(profile.GPGKeyIDs(), ", "))
+	fmt.Printf("ssh keys: %s\n", strings.Join(profile.SSHKeyFingerprints(), ", "))
+}
+
+func editUser(ctx context.Context, cmd *cobra.Command, client *dgit.Client, repo *dgit.Repo) {
+	username, err := repo.Username()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	profile, err := client.UserProfile(ctx, username)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// only the given flags are changed, so a flag set to "" clears its field
+	flags := cmd.Flags()
+	if flags.Changed("display-name") {
+		profile.DisplayName = userDisplayName
+	}
+	if flags.Changed("email") {
+		profile.Email = userEmail
+	}
+	if flags.Changed("avatar") {
+		profile.Avatar = userAvatar
+	}
+	if flags.Changed("website") {
+		profile.Website = userWebsite
+	}
+	if flags.Changed("gpg-key") {
+		profile.GPGKeys = readKeyFiles(userGPGKeys)
+	}
+	if flags.Changed("ssh-key") {
+		profile.SSHKeys = readKeyFiles(userSSHKeys)
+	}
+
+	err = client.SetUserProfile(ctx, repo, profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	showUser(ctx, client, username)
+}
+
+// readKeyFiles returns the contents of each key file, an empty path is
+// skipped so that --ssh-key "" removes all keys
+func readKeyFiles(paths []string) []string {
+	keys := []string{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		key, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		keys = append(keys, strings.TrimSpace(string(key)))
+	}
+	return keys
+}
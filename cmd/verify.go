@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/transport/dgit"
+)
+
+func init() {
+	rootCmd.AddCommand(verifyCommitCommand)
+	rootCmd.AddCommand(verifyTagCommand)
+}
+
+var verifyCommitCommand = &cobra.Command{
+	Use:   "verify-commit [commits]",
+	Short: "Check commits are signed by their author, a collaborator of the decentragit repo",
+	Long: `Verifies GPG and SSH commit signatures against the keys published in the profile
+of the repo collaborator whose profile email is the commit author's, and prints which
+decentragit user signed each commit.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runVerify(cmd, args, func(repo *dgit.Repo, verifier *dgit.Verifier, arg string) (*dgit.Verification, error) {
+			hash, err := repo.ResolveRevision(plumbing.Revision(arg))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", arg, err)
+			}
+
+			commit, err := repo.CommitObject(*hash)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", arg, err)
+			}
+
+			return verifier.VerifyCommit(commit), nil
+		})
+	},
+}
+
+var verifyTagCommand = &cobra.Command{
+	Use:   "verify-tag [tags]",
+	Short: "Check tags are signed by their tagger, a collaborator of the decentragit repo",
+	Long: `Verifies GPG and SSH tag signatures against the keys published in the profile
+of the repo collaborator whose profile email is the tagger's, and prints which
+decentragit user signed each tag.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runVerify(cmd, args, func(repo *dgit.Repo, verifier *dgit.Verifier, arg string) (*dgit.Verification, error) {
+			ref, err := repo.Tag(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", arg, err)
+			}
+
+			tag, err := repo.TagObject(ref.Hash())
+			if err == plumbing.ErrObjectNotFound {
+				return nil, fmt.Errorf("%s: lightweight tags can not be signed", arg)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", arg, err)
+			}
+
+			return verifier.VerifyTag(tag), nil
+		})
+	},
+}
+
+type verifyFunc func(repo *dgit.Repo, verifier *dgit.Verifier, arg string) (*dgit.Verification, error)
+
+func runVerify(cmd *cobra.Command, args []string, verify verifyFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	callingDir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+		os.Exit(1)
+	}
+
+	repo := openRepo(cmd, callingDir)
+
+	client, err := newClient(ctx, repo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	repoName, err := repo.Name()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	verifier, err := client.NewVerifier(ctx, repoName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, arg := range args {
+		verification, err := verify(repo, verifier, arg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+
+		if verification.Err != nil {
+			fmt.Fprintln(os.Stderr, verification)
+			failed = true
+			continue
+		}
+		fmt.Println(verification)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/quorumcontrol/dgit/hooks"
+	"github.com/quorumcontrol/dgit/transport/dgit"
+)
+
+var (
+	watchFetch bool
+	watchExec  string
+)
+
+func init() {
+	watchCommand.Flags().BoolVar(&watchFetch, "fetch", false, "run git fetch from the dg remote when refs change")
+	watchCommand.Flags().StringVar(&watchExec, "exec", "", "shell command to run when refs change, given the changes on stdin")
+	rootCmd.AddCommand(watchCommand)
+}
+
+var watchCommand = &cobra.Command{
+	Use:   "watch",
+	Short: "Print changes to your repo as they happen and run hooks for them",
+	Long: `watch subscribes to new blocks of the repo's ChainTree and those of its teams, and
+prints each ref they create, update or delete and other changes, until interrupted.
+
+With --fetch, git fetch is run from the dg remote after each change. With --exec, the
+command is run by the shell after each change, and given a line per changed ref on
+stdin in the same "<old> <new> <ref>" format as git's post-receive hook, with zeros
+for a created or deleted ref's missing value.
+
+Hooks set in git config are run for each event, with a JSON description of it on
+stdin:
+
+  git config --add decentragit.hooks.ref-updated ./ci/trigger.sh
+  git config --add decentragit.hooks.team-changed ./ci/audit.sh
+  git config --add decentragit.hooks.repo-config-changed ./ci/audit.sh`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		callingDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting current workdir: %w", err)
+			os.Exit(1)
+		}
+
+		repo := openRepo(cmd, callingDir)
+
+		client, err := newClient(ctx, repo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		repoName, err := repo.Name()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		repoConfig, err := repo.Config()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		hookRunner := hooks.FromConfig(repoConfig, callingDir)
+
+		fmt.Printf("Watching %s for changes\n", repoName)
+
+		err = client.WatchRepoEvents(ctx, repoName, func(payload *hooks.Payload) error {
+			switch payload.Event {
+			case hooks.RefUpdated:
+				for _, ref := range payload.Refs {
+					fmt.Println(describeRef(ref))
+				}
+
+				if watchFetch {
+					if err := runWatchFetch(repo); err != nil {
+						fmt.Fprintln(os.Stderr, err)
+					}
+				}
+
+				if watchExec != "" {
+					if err := runWatchExec(watchExec, payload.Refs); err != nil {
+						fmt.Fprintln(os.Stderr, err)
+					}
+				}
+			case hooks.TeamChanged:
+				for _, team := range payload.Teams {
+					fmt.Printf("team %s (%s): %s\n", team.Name, team.Role, strings.Join(append(team.Members, team.Teams...), ", "))
+				}
+			case hooks.RepoConfigChanged:
+				fmt.Printf("config changed: %s\n", strings.Join(payload.Changed, ", "))
+			}
+
+			if err := hookRunner.Run(ctx, payload); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func describeRef(ref *hooks.Ref) string {
+	switch {
+	case ref.IsCreate():
+		return fmt.Sprintf("%s created at %s", ref.Name, ref.New[:7])
+	case ref.IsDelete():
+		return fmt.Sprintf("%s deleted, was %s", ref.Name, ref.Old[:7])
+	default:
+		return fmt.Sprintf("%s updated %s..%s", ref.Name, ref.Old[:7], ref.New[:7])
+	}
+}
+
+func runWatchFetch(repo *dgit.Repo) error {
+	remote, err := repo.RemoteName()
+	if err != nil {
+		return err
+	}
+	if remote == "" {
+		remote = repo.MustURL()
+	}
+
+	fetch := exec.Command("git", "fetch", remote)
+	fetch.Stdout = os.Stdout
+	fetch.Stderr = os.Stderr
+	if err := fetch.Run(); err != nil {
+		return fmt.Errorf("error fetching from %s: %w", remote, err)
+	}
+	return nil
+}
+
+func runWatchExec(command string, refs []*hooks.Ref) error {
+	lines := make([]string, len(refs))
+	for i, ref := range refs {
+		lines[i] = fmt.Sprintf("%s %s %s\n", ref.Old, ref.New, ref.Name)
+	}
+
+	hook := exec.Command("sh", "-c", command)
+	hook.Stdin = strings.NewReader(strings.Join(lines, ""))
+	hook.Stdout = os.Stdout
+	hook.Stderr = os.Stderr
+	if err := hook.Run(); err != nil {
+		return fmt.Errorf("error running %q: %w", command, err)
+	}
+	return nil
+}
@@ -13,7 +13,7 @@ func init() {
 
 var whoAmICommand = &cobra.Command{
 	Use:   "whoami",
-	Short: "Print out your username in the current repo",
+	Short: "Print out your username in the current repo, and which setting selected it",
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		callingDir, err := os.Getwd()
@@ -24,12 +24,14 @@ var whoAmICommand = &cobra.Command{
 
 		repo := openRepo(cmd, callingDir)
 
-		username, err := repo.Username()
+		identity, err := repo.Identity()
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
-		fmt.Println(username)
+		fmt.Println(identity.Username)
+		// on stderr so scripts can keep reading the username from stdout
+		fmt.Fprintf(os.Stderr, "selected by %s\n", identity.Reason)
 	},
 }
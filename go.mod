@@ -30,6 +30,7 @@ require (
 	github.com/go-git/go-git-fixtures/v4 v4.0.1
 	github.com/go-git/go-git/v5 v5.0.1-0.20200319142726-f6305131a06b
 	github.com/ipfs/go-bitswap v0.1.9-0.20191015150653-291b2674f1f1
+	github.com/ipfs/go-cid v0.0.3
 	github.com/ipfs/go-datastore v0.4.4
 	github.com/ipfs/go-ds-flatfs v0.4.0
 	github.com/ipfs/go-ipfs-blockstore v0.1.0
@@ -44,9 +45,10 @@ require (
 	github.com/steakknife/hamming v0.0.0-20180906055917-c99c65617cd3 // indirect
 	github.com/stretchr/testify v1.4.0
 	github.com/tyler-smith/go-bip39 v1.0.2
+	go.dedis.ch/kyber/v3 v3.0.12
 	go.uber.org/multierr v1.5.0 // indirect
 	go.uber.org/zap v1.14.0
-	golang.org/x/crypto v0.0.0-20200317142112-1b76d66859c6 // indirect
+	golang.org/x/crypto v0.0.0-20200317142112-1b76d66859c6
 	golang.org/x/lint v0.0.0-20200130185559-910be7a94367 // indirect
 	golang.org/x/sys v0.0.0-20200317113312-5766fd39f98d // indirect
 	golang.org/x/tools v0.0.0-20200226224502-204d844ad48d // indirect
@@ -0,0 +1,167 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+
+	"github.com/quorumcontrol/dgit/constants"
+)
+
+// Hooks are local commands run when a watched repo changes, so that things
+// like CI can be triggered by pushes to the dg remote. Subsection is the git
+// config subsection they are set in, as decentragit.hooks.<event>
+const Subsection = "hooks"
+
+type Event string
+
+const (
+	// RefUpdated is sent when refs are created, updated or deleted
+	RefUpdated Event = "ref-updated"
+	// TeamChanged is sent when a team is added, removed or given another
+	// role, or its members or invitations change
+	TeamChanged Event = "team-changed"
+	// RepoConfigChanged is sent when the repo's config or metadata change,
+	// such as its protection rules or description
+	RepoConfigChanged Event = "repo-config-changed"
+)
+
+var Events = []Event{RefUpdated, TeamChanged, RepoConfigChanged}
+
+// Payload is the JSON document hooks are given on stdin
+type Payload struct {
+	Event Event  `json:"event"`
+	Repo  string `json:"repo"`
+	// Did and Tip are of the chaintree whose change caused the event, which
+	// is a team's chaintree for team member changes
+	Did  string    `json:"did"`
+	Tip  string    `json:"tip"`
+	Time time.Time `json:"time"`
+
+	// Refs are set for ref-updated
+	Refs []*Ref `json:"refs,omitempty"`
+	// Teams are set for team-changed, and are all teams of the repo after
+	// the change
+	Teams []*Team `json:"teams,omitempty"`
+	// Changed are the config paths which changed for repo-config-changed,
+	// such as config/protectedRefs or metadata
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Ref is a changed ref, with Old or New set to zeros when it was created or
+// deleted
+type Ref struct {
+	Name string `json:"name"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+func (r *Ref) IsCreate() bool {
+	return r.Old == plumbing.ZeroHash.String()
+}
+
+func (r *Ref) IsDelete() bool {
+	return r.New == plumbing.ZeroHash.String()
+}
+
+type Team struct {
+	Name    string   `json:"name"`
+	Role    string   `json:"role"`
+	Members []string `json:"members"`
+	// Teams are the teams included in this one
+	Teams   []string `json:"teams,omitempty"`
+	Invited []string `json:"invited,omitempty"`
+}
+
+// Runner runs the commands configured for each event
+type Runner struct {
+	Commands map[Event][]string
+	// Dir is the directory commands run in
+	Dir    string
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// FromConfig returns a runner of the commands set in
+// decentragit.hooks.<event>, an event can be given several with
+// git config --add, in the system, global and local config
+func FromConfig(cfg *config.Config, dir string) *Runner {
+	r := &Runner{
+		Commands: make(map[Event][]string),
+		Dir:      dir,
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+	}
+
+	// the merged config keeps only the last value of each option, so the
+	// commands of every scope are read like git config --get-all would
+	for scope := format.SystemScope; scope >= format.LocalScope; scope-- {
+		scoped := cfg.Merged.ScopedConfig(scope)
+		if scoped == nil {
+			continue
+		}
+
+		for _, section := range scoped.Sections {
+			if !section.IsName(constants.DgitConfigSection) {
+				continue
+			}
+
+			for _, subsection := range section.Subsections {
+				if !subsection.IsName(Subsection) {
+					continue
+				}
+
+				for _, event := range Events {
+					if commands := subsection.Options.GetAll(string(event)); len(commands) > 0 {
+						r.Commands[event] = append(r.Commands[event], commands...)
+					}
+				}
+			}
+		}
+	}
+
+	return r
+}
+
+// Run runs each command configured for the payload's event with the shell,
+// in order. Failing commands don't stop the others, their errors are
+// returned together.
+func (r *Runner) Run(ctx context.Context, payload *Payload) error {
+	commands := r.Commands[payload.Event]
+	if len(commands) == 0 {
+		return nil
+	}
+
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, command := range commands {
+		hook := exec.CommandContext(ctx, "sh", "-c", command)
+		hook.Dir = r.Dir
+		hook.Env = append(os.Environ(), "DG_HOOK_EVENT="+string(payload.Event), "DG_REPO="+payload.Repo)
+		hook.Stdin = bytes.NewReader(input)
+		hook.Stdout = r.Stdout
+		hook.Stderr = r.Stderr
+		if err := hook.Run(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s hook %q: %v", payload.Event, command, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error running hooks:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
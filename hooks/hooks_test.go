@@ -0,0 +1,99 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/config"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	err := cfg.UnmarshalScoped(format.GlobalScope, []byte(`
+[decentragit "hooks"]
+	ref-updated = ~/bin/log-push.sh
+`))
+	require.Nil(t, err)
+	err = cfg.UnmarshalScoped(format.LocalScope, []byte(`
+[decentragit]
+	username = alice
+[decentragit "hooks"]
+	ref-updated = ./ci/trigger.sh
+	ref-updated = ./ci/notify.sh
+	team-changed = ./ci/audit.sh
+	unknown-event = ./nope.sh
+`))
+	require.Nil(t, err)
+
+	runner := FromConfig(cfg, "/repo")
+	require.Equal(t, map[Event][]string{
+		RefUpdated:  {"~/bin/log-push.sh", "./ci/trigger.sh", "./ci/notify.sh"},
+		TeamChanged: {"./ci/audit.sh"},
+	}, runner.Commands)
+	require.Equal(t, "/repo", runner.Dir)
+
+	require.Empty(t, FromConfig(config.NewConfig(), "/repo").Commands)
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "dgit-hooks")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	stderr := &bytes.Buffer{}
+	runner := &Runner{
+		Commands: map[Event][]string{
+			RefUpdated: {
+				`cat > payload.json`,
+				`echo "$DG_HOOK_EVENT $DG_REPO" > env`,
+				`exit 3`,
+			},
+		},
+		Dir:    dir,
+		Stdout: ioutil.Discard,
+		Stderr: stderr,
+	}
+
+	payload := &Payload{
+		Event: RefUpdated,
+		Repo:  "alice/repo",
+		Did:   "did:tupelo:0x1",
+		Tip:   "bafy",
+		Time:  time.Unix(100, 0).UTC(),
+		Refs: []*Ref{{
+			Name: "refs/heads/master",
+			Old:  "0000000000000000000000000000000000000000",
+			New:  "6ecf0ef2c2dffb796033e5a02219af86ec6584e5",
+		}},
+	}
+
+	err = runner.Run(ctx, payload)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), `"exit 3"`)
+
+	written, err := ioutil.ReadFile(filepath.Join(dir, "payload.json"))
+	require.Nil(t, err)
+
+	decoded := &Payload{}
+	require.Nil(t, json.Unmarshal(written, decoded))
+	require.Equal(t, payload, decoded)
+	require.True(t, decoded.Refs[0].IsCreate())
+
+	env, err := ioutil.ReadFile(filepath.Join(dir, "env"))
+	require.Nil(t, err)
+	require.Equal(t, "ref-updated alice/repo\n", string(env))
+
+	// events without hooks run nothing
+	require.Nil(t, runner.Run(ctx, &Payload{Event: TeamChanged}))
+}
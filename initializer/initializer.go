@@ -200,6 +200,12 @@ func (i *Initializer) getAuth(ctx context.Context) (transport.AuthMethod, error)
 }
 
 func (i *Initializer) createPrivateKey(ctx context.Context, username string) (*ecdsa.PrivateKey, error) {
+	// users and orgs share the namespace of repo urls, so the user tree
+	// and an org of the same name could never both be found
+	if err := repotree.CheckNameAvailable(ctx, username, i.tupelo); err != nil {
+		return nil, err
+	}
+
 	entropy, err := bip39.NewEntropy(256)
 	if err != nil {
 		return nil, fmt.Errorf("error generating entropy for mnemoic seed: %w", err)
@@ -235,11 +241,9 @@ func (i *Initializer) createPrivateKey(ctx context.Context, username string) (*e
 	})
 	fmt.Fprintln(i.stdout)
 
-	seedSlice := strings.Split(string(mnemonic), " ")
-
 	msg.Fprint(i.stdout, msg.UserSeedPhraseCreated, map[string]interface{}{
 		"username": username,
-		"seed":     " " + strings.Join(seedSlice[0:8], " ") + "\n " + strings.Join(seedSlice[8:16], " ") + "\n " + strings.Join(seedSlice[16:], " "),
+		"seed":     FormatSeedPhrase(mnemonic),
 	})
 	fmt.Fprintln(i.stdout)
 
@@ -342,7 +346,7 @@ func (i *Initializer) findOrCreateRepoTree(ctx context.Context) (*repotree.RepoT
 
 	// repo doesn't exist, create it
 	log.Debugf("creating new repo tree with endpoint %+v and auth %+v", i.repo.MustEndpoint(), auth)
-	newTree, err := client.CreateRepoTree(ctx, i.repo.MustEndpoint(), auth)
+	newTree, err := client.CreateRepoTree(ctx, i.repo.MustEndpoint(), auth, detectMetadata(i.repo))
 	if errors.Is(err, usertree.ErrNotFound) {
 		return nil, fmt.Errorf(msg.Parse(msg.UserNotFound, map[string]interface{}{
 			"user": strings.Split(i.repo.MustName(), "/")[0],
@@ -503,17 +507,7 @@ func (i *Initializer) addDgitPushToRemote(ctx context.Context, remoteName string
 
 	remoteConfig.URLs = append(remoteConfig.URLs, i.repo.MustURL())
 
-	newConfig, err := i.repo.Config()
-	if err != nil {
-		return err
-	}
-	newConfig.Remotes[remoteConfig.Name] = remoteConfig
-	err = newConfig.Validate()
-	if err != nil {
-		return err
-	}
-
-	err = i.repo.Storer.SetConfig(newConfig)
+	err = setRemoteConfig(i.repo, remoteConfig)
 	if err != nil {
 		return err
 	}
@@ -572,17 +566,7 @@ func (i *Initializer) addDgitRemote(ctx context.Context) error {
 		return err
 	}
 
-	newConfig, err := i.repo.Config()
-	if err != nil {
-		return err
-	}
-	newConfig.Remotes[remoteConfig.Name] = remoteConfig
-	err = newConfig.Validate()
-	if err != nil {
-		return err
-	}
-
-	err = i.repo.Storer.SetConfig(newConfig)
+	err = setRemoteConfig(i.repo, remoteConfig)
 	if err != nil {
 		return err
 	}
@@ -596,6 +580,53 @@ func (i *Initializer) addDgitRemote(ctx context.Context) error {
 	return nil
 }
 
+// UpdateRemoteURLs replaces oldURL with newURL in every remote of the repo,
+// returning the names of the remotes that changed. Used after a dg repo has
+// been renamed or transferred.
+func UpdateRemoteURLs(repo *dgit.Repo, oldURL string, newURL string) ([]string, error) {
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return nil, err
+	}
+
+	updated := []string{}
+	for _, remote := range remotes {
+		remoteConfig := remote.Config()
+		changed := false
+		for i, url := range remoteConfig.URLs {
+			if url == oldURL {
+				remoteConfig.URLs[i] = newURL
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		err = setRemoteConfig(repo, remoteConfig)
+		if err != nil {
+			return nil, err
+		}
+		updated = append(updated, remoteConfig.Name)
+	}
+
+	return updated, nil
+}
+
+func setRemoteConfig(repo *dgit.Repo, remoteConfig *config.RemoteConfig) error {
+	newConfig, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	newConfig.Remotes[remoteConfig.Name] = remoteConfig
+	err = newConfig.Validate()
+	if err != nil {
+		return err
+	}
+
+	return repo.Storer.SetConfig(newConfig)
+}
+
 func newDgitEndpoint(user string, repo string) (*transport.Endpoint, error) {
 	// the New(String()) is for parsing validation
 	return transport.NewEndpoint((&transport.Endpoint{
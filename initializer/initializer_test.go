@@ -0,0 +1,47 @@
+package initializer
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quorumcontrol/dgit/transport/dgit"
+)
+
+func TestUpdateRemoteURLs(t *testing.T) {
+	gitRepo, err := git.Init(memory.NewStorage(), nil)
+	require.Nil(t, err)
+
+	_, err = gitRepo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"https://github.com/quorumcontrol/dgit.git", "dg://quorumcontrol/dgit"},
+	})
+	require.Nil(t, err)
+
+	_, err = gitRepo.CreateRemote(&config.RemoteConfig{
+		Name: "dg",
+		URLs: []string{"dg://quorumcontrol/dgit"},
+	})
+	require.Nil(t, err)
+
+	_, err = gitRepo.CreateRemote(&config.RemoteConfig{
+		Name: "other",
+		URLs: []string{"dg://quorumcontrol/other"},
+	})
+	require.Nil(t, err)
+
+	updated, err := UpdateRemoteURLs(dgit.NewRepo(gitRepo), "dg://quorumcontrol/dgit", "dg://decentragit/dgit")
+	require.Nil(t, err)
+	require.ElementsMatch(t, []string{"origin", "dg"}, updated)
+
+	origin, err := gitRepo.Remote("origin")
+	require.Nil(t, err)
+	require.Equal(t, []string{"https://github.com/quorumcontrol/dgit.git", "dg://decentragit/dgit"}, origin.Config().URLs)
+
+	other, err := gitRepo.Remote("other")
+	require.Nil(t, err)
+	require.Equal(t, []string{"dg://quorumcontrol/other"}, other.Config().URLs)
+}
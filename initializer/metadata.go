@@ -0,0 +1,169 @@
+package initializer
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+
+	"github.com/quorumcontrol/dgit/transport/dgit"
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
+)
+
+var readmeFileName = regexp.MustCompile(`(?i)^readme(\.[a-z]+)?$`)
+
+var licenseFileName = regexp.MustCompile(`(?i)^(license|licence|copying)(\.[a-z]+)?$`)
+
+// licensePatterns identify common licenses by phrases of their text, more
+// specific licenses must come before the ones they contain
+var licensePatterns = []struct {
+	spdx    string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"GNU AFFERO GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"LGPL-3.0", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"LGPL-2.1", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 2.1"}},
+	{"GPL-3.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"GPL-2.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 2"}},
+	{"Apache-2.0", []string{"Apache License", "Version 2.0"}},
+	{"MPL-2.0", []string{"Mozilla Public License", "Version 2.0"}},
+	{"Unlicense", []string{"This is free and unencumbered software released into the public domain"}},
+	{"ISC", []string{"Permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"MIT", []string{"Permission is hereby granted, free of charge"}},
+	{"BSD-3-Clause", []string{"Redistribution and use in source and binary forms", "Neither the name"}},
+	{"BSD-2-Clause", []string{"Redistribution and use in source and binary forms"}},
+}
+
+// defaultDescription is what git init writes to .git/description
+const defaultDescription = "Unnamed repository"
+
+// detectMetadata fills in what it can of the repo metadata from the local
+// repo: the readme and license of the HEAD commit, .git/description and
+// the web url of the origin remote
+func detectMetadata(repo *dgit.Repo) *repotree.Metadata {
+	metadata := &repotree.Metadata{
+		Description: localDescription(repo),
+		Homepage:    originHomepage(repo),
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		log.Debugf("no HEAD to detect metadata from: %v", err)
+		return metadata
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return metadata
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return metadata
+	}
+
+	for _, entry := range tree.Entries {
+		if !entry.Mode.IsFile() {
+			continue
+		}
+
+		if metadata.Readme == "" && readmeFileName.MatchString(entry.Name) {
+			metadata.Readme = entry.Name
+		}
+
+		if metadata.License == "" && licenseFileName.MatchString(entry.Name) {
+			file, err := tree.TreeEntryFile(&entry)
+			if err != nil {
+				continue
+			}
+			metadata.License = detectLicense(file)
+		}
+	}
+
+	return metadata
+}
+
+func detectLicense(file *object.File) string {
+	contents, err := file.Contents()
+	if err != nil {
+		return ""
+	}
+	return licenseFromText(contents)
+}
+
+// licenseFromText returns the SPDX id of the license text or "" if it
+// isn't recognized
+func licenseFromText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	for _, pattern := range licensePatterns {
+		matches := true
+		for _, phrase := range pattern.phrases {
+			if !strings.Contains(strings.ToLower(text), strings.ToLower(phrase)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return pattern.spdx
+		}
+	}
+
+	return ""
+}
+
+func localDescription(repo *dgit.Repo) string {
+	fsStorage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return ""
+	}
+
+	file, err := fsStorage.Filesystem().Open("description")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	description, err := ioutil.ReadAll(file)
+	if err != nil {
+		return ""
+	}
+
+	descriptionStr := strings.TrimSpace(string(description))
+	if strings.HasPrefix(descriptionStr, defaultDescription) {
+		return ""
+	}
+
+	return descriptionStr
+}
+
+// originHomepage returns the web url of an http(s) or github ssh origin
+// remote
+func originHomepage(repo *dgit.Repo) string {
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return ""
+	}
+
+	for _, url := range remote.Config().URLs {
+		endpoint, err := transport.NewEndpoint(url)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case endpoint.Protocol == "http" || endpoint.Protocol == "https":
+		case endpoint.Protocol == "ssh" && endpoint.Host == "github.com":
+		default:
+			continue
+		}
+
+		path := strings.TrimSuffix(strings.TrimPrefix(endpoint.Path, "/"), ".git")
+		return "https://" + endpoint.Host + "/" + path
+	}
+
+	return ""
+}
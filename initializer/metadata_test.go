@@ -0,0 +1,54 @@
+package initializer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quorumcontrol/dgit/transport/dgit"
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
+)
+
+func TestLicenseFromText(t *testing.T) {
+	require.Equal(t, "MIT", licenseFromText("MIT License\n\nPermission is hereby granted,\nfree of charge, to any person"))
+	require.Equal(t, "Apache-2.0", licenseFromText("Apache License\n   Version 2.0, January 2004"))
+	require.Equal(t, "LGPL-3.0", licenseFromText("GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007"))
+	require.Equal(t, "", licenseFromText("All rights reserved."))
+}
+
+func TestDetectMetadata(t *testing.T) {
+	fs := memfs.New()
+	gitRepo, err := git.Init(memory.NewStorage(), fs)
+	require.Nil(t, err)
+
+	_, err = gitRepo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"git@github.com:quorumcontrol/dgit.git"},
+	})
+	require.Nil(t, err)
+
+	require.Nil(t, util.WriteFile(fs, "README.md", []byte("# dgit"), 0644))
+	require.Nil(t, util.WriteFile(fs, "LICENSE", []byte("Permission is hereby granted, free of charge"), 0644))
+
+	worktree, err := gitRepo.Worktree()
+	require.Nil(t, err)
+	_, err = worktree.Add(".")
+	require.Nil(t, err)
+	_, err = worktree.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	require.Nil(t, err)
+
+	require.Equal(t, &repotree.Metadata{
+		Homepage: "https://github.com/quorumcontrol/dgit",
+		License:  "MIT",
+		Readme:   "README.md",
+	}, detectMetadata(dgit.NewRepo(gitRepo)))
+}
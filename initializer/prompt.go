@@ -0,0 +1,78 @@
+package initializer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+)
+
+// Confirm asks the user a yes or no question which defaults to no,
+// returning false if they decline
+func Confirm(label string, stdin io.ReadCloser, stdout io.WriteCloser) (bool, error) {
+	templates := *promptTemplates
+	templates.Confirm = `{{ . }} {{ "y/N" | bold }} `
+
+	prompt := promptui.Prompt{
+		Label:     stripNewLines(label),
+		Templates: &templates,
+		IsConfirm: true,
+		Stdin:     stdin,
+		Stdout:    stdout,
+	}
+	_, err := prompt.Run()
+	fmt.Fprintln(stdout)
+	if err == promptui.ErrAbort {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FormatSeedPhrase splits a 24 word recovery phrase over three lines
+func FormatSeedPhrase(mnemonic string) string {
+	seedSlice := strings.Split(mnemonic, " ")
+	return " " + strings.Join(seedSlice[0:8], " ") + "\n " + strings.Join(seedSlice[8:16], " ") + "\n " + strings.Join(seedSlice[16:], " ")
+}
+
+// Passphrase asks for a passphrase without echoing it. If confirm is set
+// it is asked for twice and both entries must match.
+func Passphrase(label string, confirm bool, stdin io.ReadCloser, stdout io.WriteCloser) (string, error) {
+	prompt := promptui.Prompt{
+		Label:     stripNewLines(label),
+		Templates: promptTemplates,
+		Mask:      '*',
+		Stdin:     stdin,
+		Stdout:    stdout,
+		Validate: func(input string) error {
+			if input == "" {
+				return fmt.Errorf("passphrase can not be empty")
+			}
+			return nil
+		},
+	}
+	passphrase, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return passphrase, nil
+	}
+
+	prompt.Label = "Repeat passphrase"
+	prompt.Validate = func(input string) error {
+		if input != passphrase {
+			return fmt.Errorf("passphrases do not match")
+		}
+		return nil
+	}
+	if _, err = prompt.Run(); err != nil {
+		return "", err
+	}
+
+	return passphrase, nil
+}
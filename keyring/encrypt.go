@@ -0,0 +1,106 @@
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	"golang.org/x/crypto/scrypt"
+)
+
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+// scrypt parameters recommended for interactive logins
+const (
+	scryptN      = 32768
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLength   = 16
+)
+
+// Encrypted is data sealed with AES-GCM under a scrypt derived key
+type Encrypted struct {
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Encrypt seals plaintext with a key derived from passphrase
+func Encrypt(passphrase string, plaintext []byte) (*Encrypted, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &Encrypted{
+		KDF:        "scrypt",
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, nil
+}
+
+// Decrypt opens e, returning ErrWrongPassphrase if it was sealed with a
+// different passphrase or has been tampered with
+func Decrypt(passphrase string, e *Encrypted) ([]byte, error) {
+	if e.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation %q", e.KDF)
+	}
+
+	gcm, err := passphraseCipher(passphrase, e.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(e.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce length %d", len(e.Nonce))
+	}
+
+	plaintext, err := gcm.Open(nil, e.Nonce, e.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	return plaintext, nil
+}
+
+func passphraseCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// EncryptToKey seals plaintext so that only the holder of the private key
+// of pub can read it
+func EncryptToKey(pub *ecdsa.PublicKey, plaintext []byte) ([]byte, error) {
+	return ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(pub), plaintext, nil, nil)
+}
+
+// DecryptWithKey opens data sealed with EncryptToKey
+func DecryptWithKey(key *ecdsa.PrivateKey, ciphertext []byte) ([]byte, error) {
+	return ecies.ImportECDSA(key).Decrypt(ciphertext, nil, nil)
+}
@@ -0,0 +1,284 @@
+package keyring
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	keyringlib "github.com/99designs/keyring"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// DefaultFileDir is where the file backend stores its encrypted keys
+const DefaultFileDir = "~/.decentragit/keyring"
+
+const fileItemSuffix = ".json"
+
+// fileKeyring stores each item in its own passphrase encrypted file. It
+// is used instead of the keyring library's file backend, whose jose based
+// encryption panics with current versions of crypto/hmac.
+type fileKeyring struct {
+	dir            string
+	passphraseFunc keyringlib.PromptFunc
+	passphrase     *string
+}
+
+var _ keyringlib.Keyring = (*fileKeyring)(nil)
+
+type fileItem struct {
+	Label string     `json:"label"`
+	Data  *Encrypted `json:"data"`
+}
+
+func newFileKeyring(dir string, passphraseFunc keyringlib.PromptFunc) (*fileKeyring, error) {
+	if strings.HasPrefix(dir, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &fileKeyring{dir: dir, passphraseFunc: passphraseFunc}, nil
+}
+
+// unlock asks for the passphrase once per keyring. With verify, it is
+// checked by decrypting an existing item, or asked for twice while the
+// keyring is empty, so that no item is encrypted with a mistyped
+// passphrase. Passphrases from the environment or a descriptor are the
+// same both times.
+func (k *fileKeyring) unlock(verify bool) (string, error) {
+	if k.passphrase != nil {
+		return *k.passphrase, nil
+	}
+
+	passphrase, err := k.passphraseFunc(fmt.Sprintf("Enter passphrase to unlock %s", k.dir))
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("keyring passphrase can not be blank")
+	}
+
+	if verify {
+		existing, err := k.anyItem()
+		if err != nil {
+			return "", err
+		}
+
+		if existing != nil {
+			if _, err := Decrypt(passphrase, existing.Data); err != nil {
+				return "", fmt.Errorf("wrong passphrase for %s: %w", k.dir, err)
+			}
+		} else {
+			confirmation, err := k.passphraseFunc(fmt.Sprintf("Enter the new passphrase of %s again", k.dir))
+			if err != nil {
+				return "", err
+			}
+			if confirmation != passphrase {
+				return "", fmt.Errorf("passphrases do not match")
+			}
+		}
+	}
+
+	k.passphrase = &passphrase
+
+	return passphrase, nil
+}
+
+func (k *fileKeyring) readItem(key string) (*fileItem, error) {
+	contents, err := ioutil.ReadFile(k.path(key))
+	if os.IsNotExist(err) {
+		return nil, keyringlib.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	item := &fileItem{}
+	if err = json.Unmarshal(contents, item); err != nil {
+		return nil, fmt.Errorf("error reading keyring file for %s: %w", key, err)
+	}
+	if item.Data == nil {
+		return nil, fmt.Errorf("keyring file for %s has no data", key)
+	}
+
+	return item, nil
+}
+
+// anyItem returns an item of the keyring, or nil if it is empty
+func (k *fileKeyring) anyItem() (*fileItem, error) {
+	keys, err := k.Keys()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return k.readItem(keys[0])
+}
+
+func (k *fileKeyring) path(key string) string {
+	return filepath.Join(k.dir, url.PathEscape(key)+fileItemSuffix)
+}
+
+func (k *fileKeyring) Get(key string) (keyringlib.Item, error) {
+	item, err := k.readItem(key)
+	if err != nil {
+		return keyringlib.Item{}, err
+	}
+
+	// decrypting the item checks the passphrase
+	passphrase, err := k.unlock(false)
+	if err != nil {
+		return keyringlib.Item{}, err
+	}
+
+	data, err := Decrypt(passphrase, item.Data)
+	if err != nil {
+		// don't keep a wrong passphrase for later Sets
+		k.passphrase = nil
+		return keyringlib.Item{}, fmt.Errorf("could not unlock %s: %w", key, err)
+	}
+
+	return keyringlib.Item{Key: key, Label: item.Label, Data: data}, nil
+}
+
+func (k *fileKeyring) GetMetadata(key string) (keyringlib.Metadata, error) {
+	return keyringlib.Metadata{}, keyringlib.ErrMetadataNeedsCredentials
+}
+
+func (k *fileKeyring) Set(item keyringlib.Item) error {
+	passphrase, err := k.unlock(true)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := Encrypt(passphrase, item.Data)
+	if err != nil {
+		return err
+	}
+
+	contents, err := json.Marshal(&fileItem{Label: item.Label, Data: encrypted})
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(k.path(item.Key), contents, 0600)
+}
+
+func (k *fileKeyring) Remove(key string) error {
+	err := os.Remove(k.path(key))
+	if os.IsNotExist(err) {
+		return keyringlib.ErrKeyNotFound
+	}
+	return err
+}
+
+func (k *fileKeyring) Keys() ([]string, error) {
+	files, err := ioutil.ReadDir(k.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), fileItemSuffix) {
+			continue
+		}
+		key, err := url.PathUnescape(strings.TrimSuffix(file.Name(), fileItemSuffix))
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// fdPassphrases caches the passphrase read from each descriptor, which is
+// closed after the first read, so asking again returns the same passphrase
+var (
+	fdPassphrases     = make(map[int]string)
+	fdPassphrasesLock sync.Mutex
+)
+
+// filePassphrase supplies the passphrase of the file backend from
+// DG_KEYRING_PASSPHRASE, a file descriptor given in DG_KEYRING_PASSPHRASE_FD,
+// or else by prompting on the terminal. Prompts go to the tty rather than
+// stdout, which git uses to talk to the remote helper.
+func filePassphrase(prompt string) (string, error) {
+	if passphrase, ok := os.LookupEnv("DG_KEYRING_PASSPHRASE"); ok {
+		return passphrase, nil
+	}
+
+	if fdStr, ok := os.LookupEnv("DG_KEYRING_PASSPHRASE_FD"); ok {
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil {
+			return "", fmt.Errorf("invalid DG_KEYRING_PASSPHRASE_FD %q: %w", fdStr, err)
+		}
+		return fdPassphrase(fd)
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("no terminal to prompt for the keyring passphrase, set DG_KEYRING_PASSPHRASE or DG_KEYRING_PASSPHRASE_FD: %w", err)
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(tty, "%s: ", prompt)
+	passphrase, err := terminal.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", err
+	}
+
+	return string(passphrase), nil
+}
+
+func fdPassphrase(fd int) (string, error) {
+	fdPassphrasesLock.Lock()
+	defer fdPassphrasesLock.Unlock()
+
+	if passphrase, ok := fdPassphrases[fd]; ok {
+		return passphrase, nil
+	}
+
+	passphrase, err := readPassphrase(os.NewFile(uintptr(fd), "passphrase"))
+	if err != nil {
+		return "", err
+	}
+	fdPassphrases[fd] = passphrase
+
+	return passphrase, nil
+}
+
+// readPassphrase reads the first line of file
+func readPassphrase(file *os.File) (string, error) {
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("error reading keyring passphrase: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func fileDir() string {
+	if dir, ok := os.LookupEnv("DG_KEYRING_DIR"); ok && dir != "" {
+		return dir
+	}
+	return DefaultFileDir
+}
@@ -0,0 +1,89 @@
+package keyring
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// KeyFileVersion is the current version of the exported key file format
+const KeyFileVersion = 1
+
+var ErrUnsupportedKeyFile = errors.New("unsupported key file version")
+
+// KeyFile is an exported decentragit identity. The private key is
+// encrypted with a passphrase, the rest is public so the identity can be
+// checked before decrypting.
+type KeyFile struct {
+	Version  int        `json:"version"`
+	Username string     `json:"username"`
+	Did      string     `json:"did"`
+	Address  string     `json:"address"`
+	Key      *Encrypted `json:"key"`
+}
+
+func NewKeyFile(username string, did string, key *ecdsa.PrivateKey, passphrase string) (*KeyFile, error) {
+	encrypted, err := Encrypt(passphrase, []byte(hexutil.Encode(crypto.FromECDSA(key))))
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyFile{
+		Version:  KeyFileVersion,
+		Username: username,
+		Did:      did,
+		Address:  crypto.PubkeyToAddress(key.PublicKey).String(),
+		Key:      encrypted,
+	}, nil
+}
+
+func ReadKeyFile(r io.Reader) (*KeyFile, error) {
+	f := &KeyFile{}
+	if err := json.NewDecoder(r).Decode(f); err != nil {
+		return nil, fmt.Errorf("error reading key file: %w", err)
+	}
+
+	if f.Version != KeyFileVersion {
+		return nil, fmt.Errorf("%w %d, this version of decentragit supports version %d", ErrUnsupportedKeyFile, f.Version, KeyFileVersion)
+	}
+	if f.Username == "" || f.Key == nil {
+		return nil, fmt.Errorf("key file is missing its username or key")
+	}
+
+	return f, nil
+}
+
+func (f *KeyFile) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(f)
+}
+
+// PrivateKey decrypts the key, checking it matches the file's address
+func (f *KeyFile) PrivateKey(passphrase string) (*ecdsa.PrivateKey, error) {
+	hexKey, err := Decrypt(passphrase, f.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := hexutil.Decode(string(hexKey))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding private key: %v", err)
+	}
+
+	key, err := crypto.ToECDSA(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal ECDSA private key: %v", err)
+	}
+
+	if addr := crypto.PubkeyToAddress(key.PublicKey).String(); addr != f.Address {
+		return nil, fmt.Errorf("key file address %s does not match its key %s", f.Address, addr)
+	}
+
+	return key, nil
+}
@@ -3,6 +3,8 @@ package keyring
 import (
 	"crypto/ecdsa"
 	"fmt"
+	"os"
+	"strings"
 
 	keyringlib "github.com/99designs/keyring"
 	"github.com/btcsuite/btcd/chaincfg"
@@ -33,16 +35,35 @@ var KeyringPrettyNames = map[string]string{
 	"*keyring.windowsKeyring": "Windows Credential Manager",
 	"*keyring.secretsKeyring": "libsecret",
 	"*keyring.passKeyring":    "pass",
+	"*keyring.fileKeyring":    "encrypted file",
 }
 
 var ErrKeyNotFound = keyringlib.ErrKeyNotFound
 
+// NewDefault opens the first available OS credential store, or only the
+// backend named by DG_KEYRING_BACKEND when set. The passphrase protected
+// file backend must be chosen explicitly with DG_KEYRING_BACKEND=file.
 func NewDefault() (*Keyring, error) {
+	backends, err := allowedBackends()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(backends) == 1 && backends[0] == keyringlib.FileBackend {
+		kr, err := newFileKeyring(fileDir(), filePassphrase)
+		if err != nil {
+			return nil, err
+		}
+		k := &Keyring{kr}
+		log.Info("keyring provider: " + k.Name())
+		return k, nil
+	}
+
 	kr, err := keyringlib.Open(keyringlib.Config{
 		ServiceName:                    "decentragit",
 		KeychainTrustApplication:       true,
 		KeychainAccessibleWhenUnlocked: true,
-		AllowedBackends:                secureKeyringBackends,
+		AllowedBackends:                backends,
 	})
 	if err != nil {
 		return nil, err
@@ -52,6 +73,29 @@ func NewDefault() (*Keyring, error) {
 	return k, nil
 }
 
+func allowedBackends() ([]keyringlib.BackendType, error) {
+	name, ok := os.LookupEnv("DG_KEYRING_BACKEND")
+	if !ok || name == "" {
+		return secureKeyringBackends, nil
+	}
+
+	backend := keyringlib.BackendType(strings.ToLower(name))
+	if backend == keyringlib.FileBackend {
+		return []keyringlib.BackendType{backend}, nil
+	}
+	for _, secure := range secureKeyringBackends {
+		if backend == secure {
+			return []keyringlib.BackendType{backend}, nil
+		}
+	}
+
+	names := []string{string(keyringlib.FileBackend)}
+	for _, secure := range secureKeyringBackends {
+		names = append(names, string(secure))
+	}
+	return nil, fmt.Errorf("invalid DG_KEYRING_BACKEND %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
 func NewMemory() *Keyring {
 	return &Keyring{keyringlib.NewArrayKeyring([]keyringlib.Item{})}
 }
@@ -65,6 +109,11 @@ func (k *Keyring) Name() string {
 	return name
 }
 
+// Names returns the names of all keys in the keyring
+func (k *Keyring) Names() ([]string, error) {
+	return k.kr.Keys()
+}
+
 func (k *Keyring) FindPrivateKey(keyName string) (key *ecdsa.PrivateKey, err error) {
 	log.Debugf("finding private key %s", keyName)
 	privateKeyItem, err := k.kr.Get(keyName)
@@ -86,6 +135,21 @@ func (k *Keyring) FindPrivateKey(keyName string) (key *ecdsa.PrivateKey, err err
 }
 
 func (k *Keyring) CreatePrivateKey(keyName string, seed []byte) (*ecdsa.PrivateKey, error) {
+	privateKey, err := PrivateKeyFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	err = k.SetPrivateKey(keyName, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return privateKey, nil
+}
+
+// PrivateKeyFromSeed derives the decentragit key of a bip39 seed
+func PrivateKeyFromSeed(seed []byte) (*ecdsa.PrivateKey, error) {
 	derivedKeyPaths, err := accounts.ParseDerivationPath("m/44'/1392825'/0'/0")
 	if err != nil {
 		return nil, err
@@ -108,20 +172,28 @@ func (k *Keyring) CreatePrivateKey(keyName string, seed []byte) (*ecdsa.PrivateK
 	}
 
 	ecPrivateKey, err := key.ECPrivKey()
-	privateKey := ecPrivateKey.ToECDSA()
+	if err != nil {
+		return nil, err
+	}
+
+	return ecPrivateKey.ToECDSA(), nil
+}
 
+// SetPrivateKey stores an existing key, replacing any key with the same
+// name
+func (k *Keyring) SetPrivateKey(keyName string, privateKey *ecdsa.PrivateKey) error {
 	privateKeyItem := keyringlib.Item{
 		Key:   keyName,
 		Label: "decentragit." + keyName,
 		Data:  []byte(hexutil.Encode(crypto.FromECDSA(privateKey))),
 	}
 
-	err = k.kr.Set(privateKeyItem)
+	err := k.kr.Set(privateKeyItem)
 	if err != nil {
-		return nil, fmt.Errorf("error saving private key for decentragit: %v", err)
+		return fmt.Errorf("error saving private key for decentragit: %v", err)
 	}
 
-	return privateKey, nil
+	return nil
 }
 
 func (k *Keyring) DeletePrivateKey(keyName string) {
@@ -0,0 +1,174 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+
+	keyringlib "github.com/99designs/keyring"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBackend(t *testing.T) {
+	os.Setenv("DG_KEYRING_BACKEND", "file")
+	os.Setenv("DG_KEYRING_DIR", t.TempDir())
+	os.Setenv("DG_KEYRING_PASSPHRASE", "correct horse battery staple")
+	defer os.Unsetenv("DG_KEYRING_BACKEND")
+	defer os.Unsetenv("DG_KEYRING_DIR")
+	defer os.Unsetenv("DG_KEYRING_PASSPHRASE")
+
+	kr, err := NewDefault()
+	require.Nil(t, err)
+	require.Equal(t, "encrypted file", kr.Name())
+
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	require.Nil(t, kr.SetPrivateKey("alice", key))
+
+	found, err := kr.FindPrivateKey("alice")
+	require.Nil(t, err)
+	require.Equal(t, crypto.FromECDSA(key), crypto.FromECDSA(found))
+
+	os.Setenv("DG_KEYRING_PASSPHRASE", "wrong")
+	kr, err = NewDefault()
+	require.Nil(t, err)
+	_, err = kr.FindPrivateKey("alice")
+	require.NotNil(t, err)
+
+	// a wrong passphrase doesn't split the keyring between passphrases
+	require.NotNil(t, kr.SetPrivateKey("bob", key))
+	kr, err = NewDefault()
+	require.Nil(t, err)
+	require.NotNil(t, kr.SetPrivateKey("bob", key))
+	_, err = kr.FindPrivateKey("bob")
+	require.True(t, errors.Is(err, ErrKeyNotFound))
+}
+
+func TestFileBackendPassphraseFD(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.Nil(t, err)
+	_, err = w.WriteString("correct horse battery staple\n")
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+
+	os.Setenv("DG_KEYRING_BACKEND", "file")
+	os.Setenv("DG_KEYRING_DIR", t.TempDir())
+	os.Setenv("DG_KEYRING_PASSPHRASE_FD", strconv.Itoa(int(r.Fd())))
+	defer os.Unsetenv("DG_KEYRING_BACKEND")
+	defer os.Unsetenv("DG_KEYRING_DIR")
+	defer os.Unsetenv("DG_KEYRING_PASSPHRASE_FD")
+	defer delete(fdPassphrases, int(r.Fd()))
+
+	kr, err := NewDefault()
+	require.Nil(t, err)
+
+	// the first key is stored with the passphrase confirmed from the
+	// descriptor, which can only be read once
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	require.Nil(t, kr.SetPrivateKey("alice", key))
+
+	kr, err = NewDefault()
+	require.Nil(t, err)
+	found, err := kr.FindPrivateKey("alice")
+	require.Nil(t, err)
+	require.Equal(t, crypto.FromECDSA(key), crypto.FromECDSA(found))
+}
+
+func TestFileKeyringConfirmsNewPassphrase(t *testing.T) {
+	prompts := []string{}
+	answers := []string{"first", "typo", "second", "second"}
+	k, err := newFileKeyring(t.TempDir(), func(prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	})
+	require.Nil(t, err)
+
+	item := keyringlib.Item{Key: "alice", Data: []byte("secret")}
+	require.NotNil(t, k.Set(item))
+	require.Nil(t, k.Set(item))
+	require.Len(t, prompts, 4)
+
+	// once the keyring has items they check the passphrase instead
+	k.passphrase = nil
+	answers = []string{"second"}
+	require.Nil(t, k.Set(keyringlib.Item{Key: "bob", Data: []byte("secret")}))
+	require.Len(t, prompts, 5)
+}
+
+func TestAllowedBackends(t *testing.T) {
+	defer os.Unsetenv("DG_KEYRING_BACKEND")
+
+	backends, err := allowedBackends()
+	require.Nil(t, err)
+	require.Equal(t, secureKeyringBackends, backends)
+
+	os.Setenv("DG_KEYRING_BACKEND", "Pass")
+	backends, err = allowedBackends()
+	require.Nil(t, err)
+	require.Len(t, backends, 1)
+
+	os.Setenv("DG_KEYRING_BACKEND", "plaintext")
+	_, err = allowedBackends()
+	require.NotNil(t, err)
+}
+
+func TestReadPassphrase(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.Nil(t, err)
+
+	_, err = w.WriteString("secret passphrase\nignored\n")
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+
+	passphrase, err := readPassphrase(r)
+	require.Nil(t, err)
+	require.Equal(t, "secret passphrase", passphrase)
+}
+
+func TestKeyFile(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+
+	keyFile, err := NewKeyFile("alice", "did:tupelo:0x1234", key, "passphrase")
+	require.Nil(t, err)
+
+	buf := &bytes.Buffer{}
+	require.Nil(t, keyFile.Write(buf))
+
+	read, err := ReadKeyFile(bytes.NewReader(buf.Bytes()))
+	require.Nil(t, err)
+	require.Equal(t, keyFile, read)
+
+	_, err = read.PrivateKey("wrong")
+	require.Equal(t, ErrWrongPassphrase, err)
+
+	decrypted, err := read.PrivateKey("passphrase")
+	require.Nil(t, err)
+	require.Equal(t, crypto.FromECDSA(key), crypto.FromECDSA(decrypted))
+
+	_, err = ReadKeyFile(bytes.NewReader([]byte(`{"version": 99, "username": "alice"}`)))
+	require.True(t, errors.Is(err, ErrUnsupportedKeyFile))
+}
+
+func TestEncryptToKey(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+
+	ciphertext, err := EncryptToKey(&key.PublicKey, []byte("share"))
+	require.Nil(t, err)
+
+	plaintext, err := DecryptWithKey(key, ciphertext)
+	require.Nil(t, err)
+	require.Equal(t, []byte("share"), plaintext)
+
+	other, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	_, err = DecryptWithKey(other, ciphertext)
+	require.NotNil(t, err)
+}
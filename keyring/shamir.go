@@ -0,0 +1,80 @@
+package keyring
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/group/edwards25519"
+	"go.dedis.ch/kyber/v3/share"
+)
+
+// Split keys are shared with kyber's Shamir secret sharing over the
+// scalars of edwards25519. Not every secp256k1 key fits in that field, so
+// the shared scalar is the seed the key is derived from. A share is its
+// index followed by the marshalled scalar.
+
+var ErrInvalidShares = errors.New("invalid secret shares")
+
+var shareSuite = edwards25519.NewBlakeSHA256Ed25519()
+
+// SplitKey generates a key split into n shares, any threshold of which
+// recreate it with CombineKey
+func SplitKey(n int, threshold int) (*ecdsa.PrivateKey, [][]byte, error) {
+	if threshold < 1 || threshold > n || n > 255 {
+		return nil, nil, fmt.Errorf("can not split a key into %d shares with a threshold of %d", n, threshold)
+	}
+
+	secret := shareSuite.Scalar().Pick(shareSuite.RandomStream())
+	key, err := keyFromSecret(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	poly := share.NewPriPoly(shareSuite, threshold, secret, shareSuite.RandomStream())
+
+	shares := make([][]byte, n)
+	for i, priShare := range poly.Shares(n) {
+		value, err := priShare.V.MarshalBinary()
+		if err != nil {
+			return nil, nil, err
+		}
+		shares[i] = append([]byte{byte(priShare.I)}, value...)
+	}
+
+	return key, shares, nil
+}
+
+// CombineKey recreates the key of SplitKey from at least threshold of its
+// shares
+func CombineKey(shares [][]byte, threshold int) (*ecdsa.PrivateKey, error) {
+	priShares := make([]*share.PriShare, len(shares))
+	for i, encoded := range shares {
+		if len(encoded) != 1+shareSuite.ScalarLen() {
+			return nil, ErrInvalidShares
+		}
+
+		value := shareSuite.Scalar()
+		if err := value.UnmarshalBinary(encoded[1:]); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidShares, err)
+		}
+		priShares[i] = &share.PriShare{I: int(encoded[0]), V: value}
+	}
+
+	secret, err := share.RecoverSecret(shareSuite, priShares, threshold, len(priShares))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidShares, err)
+	}
+
+	return keyFromSecret(secret)
+}
+
+func keyFromSecret(secret kyber.Scalar) (*ecdsa.PrivateKey, error) {
+	seed, err := secret.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.ToECDSA(crypto.Keccak256(seed))
+}
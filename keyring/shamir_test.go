@@ -0,0 +1,37 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitKey(t *testing.T) {
+	key, shares, err := SplitKey(5, 3)
+	require.Nil(t, err)
+	require.Len(t, shares, 5)
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		picked := [][]byte{}
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		combined, err := CombineKey(picked, 3)
+		require.Nil(t, err)
+		require.Equal(t, crypto.FromECDSA(key), crypto.FromECDSA(combined))
+	}
+
+	_, err = CombineKey(shares[:2], 3)
+	require.True(t, errors.Is(err, ErrInvalidShares))
+
+	_, err = CombineKey([][]byte{shares[0], shares[0], shares[1]}, 3)
+	require.True(t, errors.Is(err, ErrInvalidShares))
+
+	_, err = CombineKey([][]byte{shares[0][:10]}, 1)
+	require.True(t, errors.Is(err, ErrInvalidShares))
+
+	_, _, err = SplitKey(2, 3)
+	require.NotNil(t, err)
+}
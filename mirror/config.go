@@ -0,0 +1,77 @@
+package mirror
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-git/go-git/v5/config"
+
+	"github.com/quorumcontrol/dgit/constants"
+)
+
+// Subsection is the git config subsection a repo's mirror is configured
+// in, as decentragit.sync.<option>
+const Subsection = "sync"
+
+const (
+	DefaultUpstream = "origin"
+	DefaultInterval = 15 * time.Minute
+	// MinInterval keeps schedules from hammering the upstream remote
+	MinInterval = time.Minute
+)
+
+// Config is how a repo is kept in sync, set in its git config
+type Config struct {
+	// Upstream is the remote fetched from
+	Upstream string
+	// Remote is the dg remote pushed to, which is the remote with the
+	// repo's dg url when empty
+	Remote   string
+	Interval time.Duration
+	// Force pushes upstream force updates, which are refused otherwise,
+	// and deletes refs the upstream no longer has
+	Force bool
+}
+
+// ConfigFrom reads decentragit.sync.upstream, remote, interval and force,
+// such as an interval of 5m or 1h
+func ConfigFrom(cfg *config.Config) (*Config, error) {
+	c := &Config{
+		Upstream: DefaultUpstream,
+		Interval: DefaultInterval,
+	}
+
+	dgitConfig := cfg.Merged.Section(constants.DgitConfigSection)
+	if dgitConfig == nil || !dgitConfig.HasSubsection(Subsection) {
+		return c, nil
+	}
+	syncConfig := dgitConfig.Subsection(Subsection)
+
+	if upstream := syncConfig.Option("upstream"); upstream != "" {
+		c.Upstream = upstream
+	}
+
+	c.Remote = syncConfig.Option("remote")
+
+	if interval := syncConfig.Option("interval"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s.%s.interval: %w", constants.DgitConfigSection, Subsection, err)
+		}
+		if d < MinInterval {
+			return nil, fmt.Errorf("%s.%s.interval must be at least %s", constants.DgitConfigSection, Subsection, MinInterval)
+		}
+		c.Interval = d
+	}
+
+	if force := syncConfig.Option("force"); force != "" {
+		b, err := strconv.ParseBool(force)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s.%s.force: %w", constants.DgitConfigSection, Subsection, err)
+		}
+		c.Force = b
+	}
+
+	return c, nil
+}
@@ -0,0 +1,216 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxBackoff caps how long a mirror which keeps failing waits between
+	// syncs
+	MaxBackoff = 6 * time.Hour
+	// SyncTimeout stops a sync stuck on an unresponsive remote, so that it
+	// doesn't hold up the other mirrors
+	SyncTimeout = 30 * time.Minute
+)
+
+// Backoff returns how long to wait before the next sync of a mirror after
+// the given number of consecutive failures, doubling the interval for each
+func Backoff(interval time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 0; i < failures; i++ {
+		delay *= 2
+		if delay >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+	return delay
+}
+
+// Status is the sync state of a mirror, written to the status file for
+// monitoring
+type Status struct {
+	Path     string `json:"path"`
+	Upstream string `json:"upstream"`
+	Remote   string `json:"remote"`
+	Interval string `json:"interval"`
+
+	LastAttempt *time.Time `json:"lastAttempt,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	NextSync    time.Time  `json:"nextSync"`
+	// Failures counts the syncs which failed since the last success
+	Failures  int    `json:"failures"`
+	LastError string `json:"lastError,omitempty"`
+	// Pushed are the refs the last successful sync pushed
+	Pushed []string `json:"pushed,omitempty"`
+	// Deleted are the refs the last successful sync deleted
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+// Daemon syncs mirrors on their schedules, one at a time
+type Daemon struct {
+	mirrors []*Mirror
+	// statusPath is the file the statuses of all mirrors are written to
+	// after each sync, skipped when empty
+	statusPath string
+	out        io.Writer
+	statuses   []*Status
+}
+
+func NewDaemon(mirrors []*Mirror, statusPath string, out io.Writer) *Daemon {
+	d := &Daemon{
+		mirrors:    mirrors,
+		statusPath: statusPath,
+		out:        out,
+		statuses:   make([]*Status, len(mirrors)),
+	}
+
+	now := time.Now()
+	for i, m := range mirrors {
+		d.statuses[i] = &Status{
+			Path:     m.Path,
+			Upstream: m.Config.Upstream,
+			Remote:   m.Config.Remote,
+			Interval: m.Config.Interval.String(),
+			NextSync: now,
+		}
+	}
+
+	return d
+}
+
+// Run syncs each mirror when it is due until ctx is done
+func (d *Daemon) Run(ctx context.Context) error {
+	if len(d.mirrors) == 0 {
+		return fmt.Errorf("no repos to sync")
+	}
+
+	for {
+		next := 0
+		for i, status := range d.statuses {
+			if status.NextSync.Before(d.statuses[next].NextSync) {
+				next = i
+			}
+		}
+
+		timer := time.NewTimer(time.Until(d.statuses[next].NextSync))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		d.sync(ctx, next)
+		if err := d.writeStatus(); err != nil {
+			fmt.Fprintf(d.out, "error writing sync status: %v\n", err)
+		}
+	}
+}
+
+// SyncOnce syncs every mirror once, returning an error if any failed
+func (d *Daemon) SyncOnce(ctx context.Context) error {
+	failed := []string{}
+	for i := range d.mirrors {
+		if err := d.sync(ctx, i); err != nil {
+			failed = append(failed, d.mirrors[i].Path)
+		}
+	}
+
+	if err := d.writeStatus(); err != nil {
+		return fmt.Errorf("error writing sync status: %w", err)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to sync %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (d *Daemon) sync(ctx context.Context, i int) error {
+	m, status := d.mirrors[i], d.statuses[i]
+
+	now := time.Now()
+	status.LastAttempt = &now
+
+	syncCtx, cancel := context.WithTimeout(ctx, SyncTimeout)
+	defer cancel()
+
+	result, err := m.Sync(syncCtx)
+	if err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+		status.NextSync = time.Now().Add(Backoff(m.Config.Interval, status.Failures))
+		fmt.Fprintf(d.out, "%s: sync failed, retrying at %s: %v\n", m.Path, status.NextSync.Format(time.RFC3339), err)
+		return err
+	}
+
+	done := time.Now()
+	status.LastSuccess = &done
+	status.Failures = 0
+	status.LastError = ""
+	status.NextSync = done.Add(m.Config.Interval)
+
+	status.Pushed = make([]string, len(result.Pushed))
+	for j, name := range result.Pushed {
+		status.Pushed[j] = name.String()
+	}
+
+	status.Deleted = make([]string, len(result.Deleted))
+	for j, name := range result.Deleted {
+		status.Deleted[j] = name.String()
+	}
+
+	if len(result.Pushed) > 0 {
+		fmt.Fprintf(d.out, "%s: pushed %s to %s\n", m.Path, strings.Join(status.Pushed, ", "), m.Config.Remote)
+	}
+	if len(result.Deleted) > 0 {
+		fmt.Fprintf(d.out, "%s: deleted %s from %s\n", m.Path, strings.Join(status.Deleted, ", "), m.Config.Remote)
+	}
+
+	return nil
+}
+
+// writeStatus replaces the status file, through a rename so monitors never
+// read a partly written file
+func (d *Daemon) writeStatus() error {
+	if d.statusPath == "" {
+		return nil
+	}
+
+	encoded, err := json.MarshalIndent(map[string]interface{}{
+		"updatedAt": time.Now(),
+		"repos":     d.statuses,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(d.statusPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(d.statusPath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(encoded, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), d.statusPath)
+}
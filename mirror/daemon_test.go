@@ -0,0 +1,57 @@
+package mirror
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, 15*time.Minute, Backoff(15*time.Minute, 0))
+	require.Equal(t, 30*time.Minute, Backoff(15*time.Minute, 1))
+	require.Equal(t, 2*time.Hour, Backoff(15*time.Minute, 3))
+	require.Equal(t, MaxBackoff, Backoff(15*time.Minute, 10))
+	require.Equal(t, MaxBackoff, Backoff(15*time.Minute, 1000))
+}
+
+func TestWriteStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dgit-sync")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	statusPath := filepath.Join(dir, "status", "sync.json")
+
+	d := NewDaemon([]*Mirror{{
+		Path:   "/repos/app",
+		Config: &Config{Upstream: "origin", Remote: "dg", Interval: 5 * time.Minute},
+	}}, statusPath, ioutil.Discard)
+	d.statuses[0].Failures = 2
+	d.statuses[0].LastError = "error fetching from origin"
+
+	require.Nil(t, d.writeStatus())
+
+	written, err := ioutil.ReadFile(statusPath)
+	require.Nil(t, err)
+
+	decoded := struct {
+		UpdatedAt time.Time `json:"updatedAt"`
+		Repos     []*Status `json:"repos"`
+	}{}
+	require.Nil(t, json.Unmarshal(written, &decoded))
+	require.Len(t, decoded.Repos, 1)
+	require.Equal(t, "/repos/app", decoded.Repos[0].Path)
+	require.Equal(t, "5m0s", decoded.Repos[0].Interval)
+	require.Equal(t, 2, decoded.Repos[0].Failures)
+	require.Equal(t, "error fetching from origin", decoded.Repos[0].LastError)
+	require.Nil(t, decoded.Repos[0].LastSuccess)
+
+	// only the status file is left behind
+	files, err := ioutil.ReadDir(filepath.Dir(statusPath))
+	require.Nil(t, err)
+	require.Len(t, files, 1)
+}
@@ -0,0 +1,297 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	logging "github.com/ipfs/go-log"
+
+	"github.com/quorumcontrol/dgit/constants"
+	"github.com/quorumcontrol/dgit/remotehelper"
+	"github.com/quorumcontrol/dgit/transport/dgit"
+)
+
+var log = logging.Logger("decentragit.mirror")
+
+// Mirror keeps the dg remote of a local repo in sync with its upstream
+// remote, such as a GitHub origin
+type Mirror struct {
+	// Path is the directory of the local repo
+	Path   string
+	Repo   *dgit.Repo
+	Config *Config
+}
+
+// Result is what a sync pushed
+type Result struct {
+	// Pushed are the dg remote refs which were updated
+	Pushed []plumbing.ReferenceName
+	// Deleted are the dg remote refs which were deleted
+	Deleted []plumbing.ReferenceName
+	// Failed are the refs the dg remote refused, such as protected branches
+	Failed []*remotehelper.PushError
+}
+
+// Open reads the mirror config of the repo at path
+func Open(path string) (*Mirror, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	gitRepo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{
+		DetectDotGit: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", path, err)
+	}
+	repo := dgit.NewRepo(gitRepo)
+
+	repoConfig, err := repo.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := ConfigFrom(repoConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if c.Remote == "" {
+		c.Remote, err = repo.RemoteName()
+		if err != nil {
+			return nil, fmt.Errorf("%s has no %s remote: %w", path, constants.Protocol, err)
+		}
+	}
+
+	if c.Upstream == c.Remote {
+		return nil, fmt.Errorf("%s: upstream remote %s is the %s remote, set %s.%s.upstream", path, c.Upstream, constants.Protocol, constants.DgitConfigSection, Subsection)
+	}
+
+	if _, err := repo.Remote(c.Upstream); err != nil {
+		return nil, fmt.Errorf("%s: upstream remote %s: %w", path, c.Upstream, err)
+	}
+
+	return &Mirror{Path: path, Repo: repo, Config: c}, nil
+}
+
+// refsPrefix is where a sync fetches the upstream's branches and tags to,
+// as refs/sync/<upstream>/heads/* and refs/sync/<upstream>/tags/*. Keeping
+// them apart from local tags means only refs which the upstream has are
+// mirrored, and pruning drops the ones it deleted.
+const refsPrefix = "refs/sync/"
+
+func upstreamPrefix(upstream string) string {
+	return refsPrefix + upstream + "/"
+}
+
+// Sync fetches from the upstream remote and pushes its branches and tags
+// which changed to the dg remote, through the remote helper's push so the
+// repo's roles and protection rules apply. With Force, branches and tags
+// the upstream deleted are deleted from the dg remote too. It returns an
+// error if any ref was refused.
+func (m *Mirror) Sync(ctx context.Context) (*Result, error) {
+	if err := m.fetch(ctx); err != nil {
+		return nil, err
+	}
+
+	remoteURL, err := m.remoteURL()
+	if err != nil {
+		return nil, err
+	}
+
+	localRefs, err := m.Repo.References()
+	if err != nil {
+		return nil, err
+	}
+	upstreamRefs, err := UpstreamRefs(localRefs, m.Config.Upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteRefs, err := m.remoteRefs(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	refSpecs := ChangedRefSpecs(m.Config.Upstream, upstreamRefs, remoteRefs, m.Config.Force)
+	if len(refSpecs) == 0 {
+		return &Result{}, nil
+	}
+
+	log.Infof("pushing %v from %s to %s", refSpecs, m.Config.Upstream, m.Config.Remote)
+
+	pushErrs, err := remotehelper.New(m.Repo.Repository).Push(ctx, m.Config.Remote, remoteURL, refSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := make(map[plumbing.ReferenceName]bool)
+	for _, pushErr := range pushErrs {
+		failed[pushErr.Ref] = true
+	}
+
+	result := &Result{Failed: pushErrs}
+	for _, refSpec := range refSpecs {
+		dst := refSpec.Dst(plumbing.ReferenceName("*"))
+		switch {
+		case failed[dst]:
+		case refSpec.IsDelete():
+			result.Deleted = append(result.Deleted, dst)
+		default:
+			result.Pushed = append(result.Pushed, dst)
+		}
+	}
+
+	if len(pushErrs) > 0 {
+		msgs := make([]string, len(pushErrs))
+		for i, pushErr := range pushErrs {
+			msgs[i] = pushErr.Error()
+		}
+		return result, fmt.Errorf("%d refs were not pushed:\n%s", len(pushErrs), strings.Join(msgs, "\n"))
+	}
+
+	return result, nil
+}
+
+// fetch updates the refs under upstreamPrefix to the upstream's branches and
+// tags, pruning the ones it deleted
+func (m *Mirror) fetch(ctx context.Context) error {
+	prefix := upstreamPrefix(m.Config.Upstream)
+	fetch := exec.CommandContext(ctx, "git", "fetch", "--prune", "--no-tags", m.Config.Upstream,
+		"+refs/heads/*:"+prefix+"heads/*",
+		"+refs/tags/*:"+prefix+"tags/*",
+	)
+	fetch.Dir = m.Path
+	if out, err := fetch.CombinedOutput(); err != nil {
+		return fmt.Errorf("error fetching from %s: %w\n%s", m.Config.Upstream, err, out)
+	}
+	return nil
+}
+
+func (m *Mirror) remoteURL() (string, error) {
+	remote, err := m.Repo.Remote(m.Config.Remote)
+	if err != nil {
+		return "", err
+	}
+
+	for _, url := range remote.Config().URLs {
+		if strings.HasPrefix(url, constants.Protocol+"://") {
+			return url, nil
+		}
+	}
+
+	return "", fmt.Errorf("remote %s has no %s:// url", m.Config.Remote, constants.Protocol)
+}
+
+// remoteRefs lists the refs of the dg remote, which are none before the
+// first push creates the repo
+func (m *Mirror) remoteRefs(url string) ([]*plumbing.Reference, error) {
+	remote := git.NewRemote(m.Repo.Storer, &config.RemoteConfig{
+		Name: m.Config.Remote,
+		URLs: []string{url},
+	})
+
+	refs, err := remote.List(&git.ListOptions{})
+	if err == transport.ErrRepositoryNotFound || err == transport.ErrEmptyRemoteRepository {
+		return nil, nil
+	}
+	return refs, err
+}
+
+// UpstreamRefs returns the local refs to mirror after fetching from
+// upstream, which are the upstream's branches and tags fetched by Sync
+func UpstreamRefs(refs storer.ReferenceIter, upstream string) ([]*plumbing.Reference, error) {
+	mirrored := []*plumbing.Reference{}
+	err := refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		if mirrorName(ref.Name(), upstream) != "" {
+			mirrored = append(mirrored, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(mirrored, func(i, j int) bool {
+		return mirrored[i].Name() < mirrored[j].Name()
+	})
+
+	return mirrored, nil
+}
+
+// mirrorName returns the dg remote ref a local ref is mirrored to, which is
+// "" for refs not mirrored
+func mirrorName(name plumbing.ReferenceName, upstream string) plumbing.ReferenceName {
+	prefix := upstreamPrefix(upstream)
+	if !strings.HasPrefix(name.String(), prefix) {
+		return ""
+	}
+
+	mirrored := plumbing.ReferenceName("refs/" + strings.TrimPrefix(name.String(), prefix))
+	if !mirrored.IsBranch() && !mirrored.IsTag() {
+		return ""
+	}
+	return mirrored
+}
+
+// ChangedRefSpecs returns refspecs pushing each upstream ref which the dg
+// remote doesn't have or points elsewhere, from where Sync fetched it to
+// since the local branches may be behind. With force, it also deletes the
+// dg remote's branches and tags which the upstream doesn't have.
+func ChangedRefSpecs(upstream string, upstreamRefs []*plumbing.Reference, remoteRefs []*plumbing.Reference, force bool) []config.RefSpec {
+	remoteHashes := make(map[plumbing.ReferenceName]plumbing.Hash, len(remoteRefs))
+	for _, ref := range remoteRefs {
+		remoteHashes[ref.Name()] = ref.Hash()
+	}
+
+	mirrored := make(map[plumbing.ReferenceName]bool, len(upstreamRefs))
+	refSpecs := []config.RefSpec{}
+	for _, ref := range upstreamRefs {
+		dst := mirrorName(ref.Name(), upstream)
+		if dst == "" {
+			continue
+		}
+		mirrored[dst] = true
+		if remoteHashes[dst] == ref.Hash() {
+			continue
+		}
+
+		spec := ref.Name().String() + ":" + dst.String()
+		if force {
+			spec = "+" + spec
+		}
+		refSpecs = append(refSpecs, config.RefSpec(spec))
+	}
+
+	if !force {
+		return refSpecs
+	}
+
+	deleted := []string{}
+	for _, ref := range remoteRefs {
+		name := ref.Name()
+		if (name.IsBranch() || name.IsTag()) && !mirrored[name] {
+			deleted = append(deleted, name.String())
+		}
+	}
+	sort.Strings(deleted)
+
+	for _, name := range deleted {
+		refSpecs = append(refSpecs, config.RefSpec(":"+name))
+	}
+
+	return refSpecs
+}
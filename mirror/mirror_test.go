@@ -0,0 +1,217 @@
+package mirror
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFrom(t *testing.T) {
+	c, err := ConfigFrom(config.NewConfig())
+	require.Nil(t, err)
+	require.Equal(t, &Config{Upstream: DefaultUpstream, Interval: DefaultInterval}, c)
+
+	cfg := config.NewConfig()
+	err = cfg.UnmarshalScoped(format.LocalScope, []byte(`
+[decentragit "sync"]
+	upstream = github
+	remote = dg
+	interval = 5m
+	force = true
+`))
+	require.Nil(t, err)
+
+	c, err = ConfigFrom(cfg)
+	require.Nil(t, err)
+	require.Equal(t, &Config{Upstream: "github", Remote: "dg", Interval: 5 * time.Minute, Force: true}, c)
+
+	for _, invalid := range []string{"interval = soon", "interval = 10s", "force = maybe"} {
+		cfg := config.NewConfig()
+		err = cfg.UnmarshalScoped(format.LocalScope, []byte("[decentragit \"sync\"]\n\t"+invalid+"\n"))
+		require.Nil(t, err)
+
+		_, err = ConfigFrom(cfg)
+		require.NotNil(t, err, invalid)
+	}
+}
+
+func TestChangedRefSpecs(t *testing.T) {
+	first := plumbing.NewHash("6ecf0ef2c2dffb796033e5a02219af86ec6584e5")
+	second := plumbing.NewHash("918c48b83bd081e863dbe1b80f8998f058cd8294")
+
+	storer := memory.NewStorage()
+	for _, ref := range []*plumbing.Reference{
+		plumbing.NewHashReference("refs/sync/origin/heads/master", second),
+		plumbing.NewHashReference("refs/sync/origin/heads/feature", first),
+		plumbing.NewHashReference("refs/sync/origin/tags/v1", first),
+		plumbing.NewHashReference("refs/sync/other/heads/master", second),
+		plumbing.NewHashReference("refs/remotes/origin/master", second),
+		plumbing.NewHashReference("refs/heads/master", first),
+		// a local tag which was never pushed upstream
+		plumbing.NewHashReference("refs/tags/wip", first),
+	} {
+		require.Nil(t, storer.SetReference(ref))
+	}
+
+	refs, err := storer.IterReferences()
+	require.Nil(t, err)
+
+	upstreamRefs, err := UpstreamRefs(refs, "origin")
+	require.Nil(t, err)
+
+	names := make([]plumbing.ReferenceName, len(upstreamRefs))
+	for i, ref := range upstreamRefs {
+		names[i] = ref.Name()
+	}
+	require.Equal(t, []plumbing.ReferenceName{
+		"refs/sync/origin/heads/feature",
+		"refs/sync/origin/heads/master",
+		"refs/sync/origin/tags/v1",
+	}, names)
+
+	remoteRefs := []*plumbing.Reference{
+		plumbing.NewSymbolicReference("HEAD", "refs/heads/master"),
+		plumbing.NewHashReference("refs/heads/master", first),
+		plumbing.NewHashReference("refs/heads/feature", first),
+		plumbing.NewHashReference("refs/tags/v0", first),
+		plumbing.NewHashReference("refs/heads/removed", first),
+	}
+
+	// branches and tags the upstream deleted are kept without force
+	require.Equal(t, []config.RefSpec{
+		"refs/sync/origin/heads/master:refs/heads/master",
+		"refs/sync/origin/tags/v1:refs/tags/v1",
+	}, ChangedRefSpecs("origin", upstreamRefs, remoteRefs, false))
+
+	require.Equal(t, []config.RefSpec{
+		"+refs/sync/origin/heads/master:refs/heads/master",
+		"+refs/sync/origin/tags/v1:refs/tags/v1",
+		":refs/heads/removed",
+		":refs/tags/v0",
+	}, ChangedRefSpecs("origin", upstreamRefs, remoteRefs, true))
+
+	require.Equal(t, []config.RefSpec{
+		"+refs/sync/origin/heads/feature:refs/heads/feature",
+		"+refs/sync/origin/heads/master:refs/heads/master",
+		"+refs/sync/origin/tags/v1:refs/tags/v1",
+	}, ChangedRefSpecs("origin", upstreamRefs, nil, true))
+}
+
+func TestMirrorName(t *testing.T) {
+	require.Equal(t, plumbing.ReferenceName("refs/heads/release/1.0"), mirrorName("refs/sync/origin/heads/release/1.0", "origin"))
+	require.Equal(t, plumbing.ReferenceName("refs/tags/v1"), mirrorName("refs/sync/origin/tags/v1", "origin"))
+	require.Equal(t, plumbing.ReferenceName(""), mirrorName("refs/tags/v1", "origin"))
+	require.Equal(t, plumbing.ReferenceName(""), mirrorName("refs/remotes/origin/master", "origin"))
+	require.Equal(t, plumbing.ReferenceName(""), mirrorName("refs/sync/origin/notes/x", "origin"))
+	require.Equal(t, plumbing.ReferenceName(""), mirrorName("refs/sync/origin-fork/heads/master", "origin"))
+}
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dgit-mirror")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	repo, err := git.PlainInit(dir, false)
+	require.Nil(t, err)
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "dg", URLs: []string{"dg://alice/app"}})
+	require.Nil(t, err)
+
+	// origin is required as the default upstream
+	_, err = Open(dir)
+	require.NotNil(t, err)
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"https://github.com/alice/app.git"}})
+	require.Nil(t, err)
+
+	m, err := Open(dir)
+	require.Nil(t, err)
+	require.Equal(t, "origin", m.Config.Upstream)
+	require.Equal(t, "dg", m.Config.Remote)
+
+	url, err := m.remoteURL()
+	require.Nil(t, err)
+	require.Equal(t, "dg://alice/app", url)
+
+	cfg, err := repo.Config()
+	require.Nil(t, err)
+	cfg.Raw.Section("decentragit").Subsection("sync").SetOption("upstream", "dg")
+	require.Nil(t, repo.Storer.SetConfig(cfg))
+
+	_, err = Open(dir)
+	require.NotNil(t, err)
+}
+
+func TestFetch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "dgit-mirror")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	upstreamDir := filepath.Join(dir, "upstream")
+	upstream, err := git.PlainInit(upstreamDir, false)
+	require.Nil(t, err)
+	worktree, err := upstream.Worktree()
+	require.Nil(t, err)
+	hash, err := worktree.Commit("initial", &git.CommitOptions{
+		Author: &object.Signature{Name: "Alice", Email: "alice@example.com", When: time.Now()},
+	})
+	require.Nil(t, err)
+	_, err = upstream.CreateTag("v1", hash, nil)
+	require.Nil(t, err)
+	require.Nil(t, upstream.Storer.SetReference(plumbing.NewHashReference("refs/heads/feature", hash)))
+
+	localDir := filepath.Join(dir, "local")
+	local, err := git.PlainInit(localDir, false)
+	require.Nil(t, err)
+	_, err = local.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{upstreamDir}})
+	require.Nil(t, err)
+	_, err = local.CreateRemote(&config.RemoteConfig{Name: "dg", URLs: []string{"dg://alice/app"}})
+	require.Nil(t, err)
+
+	m, err := Open(localDir)
+	require.Nil(t, err)
+
+	mirrored := func() []plumbing.ReferenceName {
+		refs, err := local.References()
+		require.Nil(t, err)
+		upstreamRefs, err := UpstreamRefs(refs, "origin")
+		require.Nil(t, err)
+
+		names := []plumbing.ReferenceName{}
+		for _, ref := range upstreamRefs {
+			names = append(names, mirrorName(ref.Name(), "origin"))
+		}
+		return names
+	}
+
+	require.Nil(t, m.fetch(ctx))
+	require.Equal(t, []plumbing.ReferenceName{"refs/heads/feature", "refs/heads/master", "refs/tags/v1"}, mirrored())
+
+	// upstream tags aren't fetched to local tags, and local tags aren't
+	// mirrored
+	_, err = local.Reference("refs/tags/v1", false)
+	require.Equal(t, plumbing.ErrReferenceNotFound, err)
+	_, err = local.CreateTag("wip", hash, nil)
+	require.Nil(t, err)
+
+	// refs the upstream deleted are pruned
+	require.Nil(t, upstream.DeleteTag("v1"))
+	require.Nil(t, upstream.Storer.RemoveReference("refs/heads/feature"))
+
+	require.Nil(t, m.fetch(ctx))
+	require.Equal(t, []plumbing.ReferenceName{"refs/heads/master"}, mirrored())
+}
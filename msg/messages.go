@@ -103,3 +103,137 @@ If you would like to create a new repo, use {{"git init" | bold | cyan}} normall
 var UsernamePrompt = `
 {{ "What decentragit username would you like to use?" | bold | green }}
 `
+
+var RepoMoved = `
+Your decentragit repo {{.from | bold | yellow}} is now {{.to | bold | yellow}}.
+
+Existing clones of {{.from | bold | yellow}} will be redirected to the new location.
+`
+
+var RemotesUpdated = `
+Updated the {{.remotes | bold }} remote(s) to {{.repourl | bold | yellow}}.
+`
+
+var RepoForked = `
+Your fork of {{.parent | bold | yellow}} has been created at {{.repourl | bold | yellow}}.
+
+This fork's unique id is {{.did | bold | yellow}}. Clone it with {{print "git clone " .repourl | bold | cyan}}.
+`
+
+var PromptRepoArchive = `
+Archive {{.repo | bold | yellow}}? It will become read-only and can not be pushed to.
+`
+
+var RepoArchived = `
+{{.repo | bold | yellow}} has been archived and is now read-only.
+`
+
+var PromptRepoDelete = `
+{{print "Permanently delete " .repo "?" | bold | red}} It will no longer be found or writable by anyone, including you.
+`
+
+var RepoDeleted = `
+{{.repo | bold | yellow}} has been deleted.
+`
+
+var DevicePairingCode = `
+This machine has a new key for {{.username | bold | yellow}}, but it is not authorized yet.
+
+On a machine that can already push as {{.username | bold | yellow}}, run:
+
+  {{print "git dg device add " .code | bold | cyan}}
+
+Once added, {{.device | bold | yellow}} can push to your decentragit repos.
+`
+
+var PromptDeviceAdd = `
+Authorize {{.device | bold | yellow}} ({{.address}}) to act as {{.username | bold | yellow}}? Only add devices you control.
+`
+
+var DeviceAdded = `
+{{.device | bold | yellow}} is now authorized to act as {{.username | bold | yellow}}.
+`
+
+var PromptDeviceRevoke = `
+Revoke {{.device | bold | yellow}}? It will no longer be able to act as your user.
+`
+
+var KeyRotationSeedPhrase = `
+Below is the new recovery phrase for {{.username | bold | yellow}}. It replaces your current key and recovery phrase once the rotation completes.
+
+{{"Please write this down in a secure location. This will be the only time the recovery phrase is displayed." | bold }}
+
+{{.seed | bold | magenta}}
+`
+
+var PromptKeyRotation = `
+Have you written down the new recovery phrase? Your current key will stop working.
+`
+
+var KeyRotated = `
+The key of {{.username | bold | yellow}} on this machine has been rotated and the previous key no longer works. Other authorized devices are unaffected. If you have guardians, they now hold shares of a new recovery key.
+`
+
+var PromptKeyExportPassphrase = `{{"Passphrase to encrypt the exported key with:" | bold | green}}`
+
+var PromptKeyImportPassphrase = `{{"Passphrase of the key file:" | bold | green}}`
+
+var KeyExported = `
+The key of {{.username | bold | yellow}} has been exported to {{.path | bold}}. Anyone with this file and its passphrase can act as {{.username | bold | yellow}}, keep both safe.
+
+Import it on another machine with {{print "git dg key import " .path | bold | cyan}}.
+`
+
+var KeyImported = `
+The key of {{.username | bold | yellow}} has been imported. This machine is now authorized to act as {{.username | bold | yellow}}.
+`
+
+var GuardianEnabled = `
+You can now be chosen as a guardian. Approving recoveries needs the guardian key stored on this machine, so keep it around.
+`
+
+var GuardiansSet = `
+Any {{.threshold | bold}} of {{.guardians | bold | yellow}} can now approve recovering your user with {{"git dg recover" | bold | cyan}}. Any previous guardians can no longer do so.
+`
+
+var PromptRecoveryApprove = `
+Approve recovering {{.username | bold | yellow}} to the key {{.address}}? {{"Only approve after confirming with them directly that this code is theirs." | bold | red}}
+`
+
+var RecoveryApproved = `
+Your approval to recover {{.username | bold | yellow}} has been recorded. They can run {{"git dg recover" | bold | cyan}} again to finish once enough guardians have approved.
+`
+
+var RecoveryCode = `
+{{.approvals}} of the {{.threshold}} guardian approvals needed to recover {{.username | bold | yellow}} have been given.
+
+Send this code to your guardians, and confirm it is you by a call or in person. Each guardian approves by running:
+
+  {{print "git dg guardian approve " .code | bold | cyan}}
+
+Then run {{"git dg recover" | bold | cyan}} again.
+`
+
+var UserRecovered = `
+{{.username | bold | yellow}} has been recovered and this machine's new key is its only owner. Your old recovery phrase, devices and guardians no longer work; set new guardians with {{"git dg guardian set" | bold | cyan}}.
+`
+
+var PromptTeamAdd = `
+Invite {{.usernames | bold | yellow}} to the {{.team | bold}} team?
+`
+
+var TeamInvited = `
+{{.username | bold | yellow}} has been invited to the {{.team | bold}} team. Send them this code, and have them run:
+
+  {{print "git dg invites accept " .code | bold | cyan}}
+
+They get access once you confirm with {{"git dg invites confirm" | bold | cyan}}.
+`
+
+var PromptInviteAccept = `
+Join {{.team | bold | yellow}} as {{.username | bold | yellow}}?
+`
+
+var InviteAccepted = `
+You accepted the invitation to {{.team | bold | yellow}}. You get access once a repo admin runs {{"git dg invites confirm" | bold | cyan}}.
+`
@@ -3,6 +3,7 @@ package remotehelper
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,23 +15,40 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	logging "github.com/ipfs/go-log"
 
 	"github.com/quorumcontrol/dgit/constants"
 	"github.com/quorumcontrol/dgit/keyring"
 	"github.com/quorumcontrol/dgit/msg"
+	"github.com/quorumcontrol/dgit/storage"
 	"github.com/quorumcontrol/dgit/transport/dgit"
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
 )
 
 var log = logging.Logger("decentragit.runner")
 
+// ErrNonFastForward is reported to git for pushes without + that would
+// lose commits on the remote
+var ErrNonFastForward = errors.New("non-fast-forward")
+
+// ErrUnverifiedSignature is reported to git for pushes of commits or tags
+// which aren't signed by their author, a repo collaborator, when
+// decentragit.verifySignatures is enabled
+var ErrUnverifiedSignature = errors.New("unverified signature")
+
 type Runner struct {
 	local   *git.Repository
 	stdin   io.Reader
 	stdout  io.Writer
 	stderr  io.Writer
 	keyring *keyring.Keyring
+
+	remoteName  string
+	remoteUrl   string
+	verifier    *dgit.Verifier
+	pushOptions []string
 }
 
 func New(local *git.Repository) *Runner {
@@ -61,26 +79,14 @@ func New(local *git.Repository) *Runner {
 func (r *Runner) Run(ctx context.Context, remoteName string, remoteUrl string) error {
 	log.Infof("running git-remote-dg on remote %s with url %s", remoteName, remoteUrl)
 
-	// get the named remote as reported by git, but then
-	// create a new remote with only the url specified
-	// this is for cases when a remote has multiple urls
-	// specified for push / fetch
-	namedRemote, err := r.local.Remote(remoteName)
-	if err != nil {
-		return err
-	}
+	r.remoteName = remoteName
+	r.remoteUrl = remoteUrl
 
-	err = namedRemote.Config().Validate()
+	remote, err := r.remote()
 	if err != nil {
-		return fmt.Errorf("Invalid remote config: %v", err)
+		return err
 	}
 
-	remote := git.NewRemote(r.local.Storer, &config.RemoteConfig{
-		Name:  namedRemote.Config().Name,
-		Fetch: namedRemote.Config().Fetch,
-		URLs:  []string{remoteUrl},
-	})
-
 	stdinReader := bufio.NewReader(r.stdin)
 
 	for {
@@ -103,8 +109,19 @@ func (r *Runner) Run(ctx context.Context, remoteName string, remoteUrl string) e
 			r.respond(strings.Join([]string{
 				"*push",
 				"*fetch",
+				"option",
 			}, "\n") + "\n")
 			r.respond("\n")
+		case "option":
+			// push options are recorded in the push certificates of the
+			// updated refs, other options are left to their defaults
+			name := strings.SplitN(args, " ", 2)[0]
+			if name != "push-option" {
+				r.respond("unsupported\n")
+				continue
+			}
+			r.pushOptions = append(r.pushOptions, strings.TrimSpace(strings.TrimPrefix(args, name)))
+			r.respond("ok\n")
 		case "list":
 			refs, err := remote.List(&git.ListOptions{})
 
@@ -126,80 +143,36 @@ func (r *Runner) Run(ctx context.Context, remoteName string, remoteUrl string) e
 				return err
 			}
 
-			var head string
-
 			listResponse := make([]string, len(refs))
 			for i, ref := range refs {
 				listResponse[i] = fmt.Sprintf("%s %s", ref.Hash(), ref.Name())
-
-				// TODO: set default branch in repo chaintree which
-				//       would become head here
-				//
-				// if master head exists, use that
-				if ref.Name() == "refs/heads/master" {
-					head = ref.Name().String()
-				}
 			}
 
 			sort.Slice(listResponse, func(i, j int) bool {
 				return strings.Split(listResponse[i], " ")[1] < strings.Split(listResponse[j], " ")[1]
 			})
 
-			// if head is empty, use last as default
-			if head == "" {
-				head = strings.Split(listResponse[len(listResponse)-1], " ")[1]
-			}
+			// TODO: set default branch in repo chaintree which
+			//       would become head here
+			head := repotree.DefaultBranch(refs)
 
 			r.respond("@%s HEAD\n", head)
 			r.respond("%s\n", strings.Join(listResponse, "\n"))
 			r.respond("\n")
 		case "push":
 			refSpec := config.RefSpec(args)
+			dst := refSpec.Dst(plumbing.ReferenceName("*"))
 
-			endpoint, err := transport.NewEndpoint(remote.Config().URLs[0])
-			if err != nil {
-				return err
+			err := r.push(ctx, remote, refSpec)
+			var pushErr *PushError
+			if errors.As(err, &pushErr) {
+				r.respond("error %s %s\n", dst, pushErr.Err.Error())
+				break
 			}
-
-			auth, err := r.auth()
 			if err != nil {
 				return err
 			}
 
-			log.Debugf("auth for push: %s %s", auth.Name(), auth.String())
-
-			err = remote.PushContext(ctx, &git.PushOptions{
-				RemoteName: remote.Config().Name,
-				RefSpecs:   []config.RefSpec{refSpec},
-				Auth:       auth,
-			})
-
-			if err == transport.ErrRepositoryNotFound {
-				err = nil // reset err back to nil
-				client, err := dgit.Default()
-				if err != nil {
-					return err
-				}
-
-				_, err = client.CreateRepoTree(ctx, endpoint, auth)
-				if err != nil {
-					return err
-				}
-
-				// Retry push now that repo exists
-				err = remote.PushContext(ctx, &git.PushOptions{
-					RemoteName: remote.Config().Name,
-					RefSpecs:   []config.RefSpec{refSpec},
-					Auth:       auth,
-				})
-			}
-
-			dst := refSpec.Dst(plumbing.ReferenceName("*"))
-			if err != nil && err != git.NoErrAlreadyUpToDate {
-				r.respond("error %s %s\n", dst, err.Error())
-				break
-			}
-
 			r.respond("ok %s\n", dst)
 			r.respond("\n")
 		case "fetch":
@@ -255,6 +228,286 @@ func (r *Runner) Run(ctx context.Context, remoteName string, remoteUrl string) e
 	}
 }
 
+// remote returns the named remote as reported by git, but with only the url
+// the helper was run with, for cases when a remote has multiple urls
+// specified for push / fetch
+func (r *Runner) remote() (*git.Remote, error) {
+	namedRemote, err := r.local.Remote(r.remoteName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = namedRemote.Config().Validate()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid remote config: %v", err)
+	}
+
+	return git.NewRemote(r.local.Storer, &config.RemoteConfig{
+		Name:  namedRemote.Config().Name,
+		Fetch: namedRemote.Config().Fetch,
+		URLs:  []string{r.remoteUrl},
+	}), nil
+}
+
+// PushError is a ref which could not be pushed. It is reported to git for
+// that ref, and doesn't stop the other refs from being pushed.
+type PushError struct {
+	Ref plumbing.ReferenceName
+	Err error
+}
+
+func (e *PushError) Error() string {
+	return fmt.Sprintf("error pushing %s: %v", e.Ref, e.Err)
+}
+
+func (e *PushError) Unwrap() error {
+	return e.Err
+}
+
+// Push pushes the refspecs to the remote the same way git push does through
+// the helper, creating the repo if it doesn't exist yet. Refs which could
+// not be pushed are returned as PushErrors, other errors stop the push.
+func (r *Runner) Push(ctx context.Context, remoteName string, remoteUrl string, refSpecs []config.RefSpec) ([]*PushError, error) {
+	r.remoteName = remoteName
+	r.remoteUrl = remoteUrl
+
+	remote, err := r.remote()
+	if err != nil {
+		return nil, err
+	}
+
+	pushErrs := []*PushError{}
+	for _, refSpec := range refSpecs {
+		err := r.push(ctx, remote, refSpec)
+		var pushErr *PushError
+		if errors.As(err, &pushErr) {
+			pushErrs = append(pushErrs, pushErr)
+			continue
+		}
+		if err != nil {
+			return pushErrs, err
+		}
+	}
+
+	return pushErrs, nil
+}
+
+// push checks and pushes a single refspec to the repo chaintree, returning
+// a PushError if the ref was refused
+func (r *Runner) push(ctx context.Context, remote *git.Remote, refSpec config.RefSpec) error {
+	endpoint, err := transport.NewEndpoint(remote.Config().URLs[0])
+	if err != nil {
+		return err
+	}
+
+	auth, err := r.auth()
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("auth for push: %s %s", auth.Name(), auth.String())
+
+	if pkAuth, ok := auth.(*dgit.PrivateKeyAuth); ok {
+		pkAuth.PushOptions = r.pushOptions
+	}
+
+	dst := refSpec.Dst(plumbing.ReferenceName("*"))
+
+	err = r.checkFastForward(remote, refSpec)
+	if err == ErrNonFastForward {
+		return &PushError{Ref: dst, Err: err}
+	}
+	if err != nil {
+		return err
+	}
+
+	err = r.checkSignatures(ctx, remote, endpoint, refSpec)
+	if errors.Is(err, ErrUnverifiedSignature) {
+		r.userMessage("%v", err)
+		return &PushError{Ref: dst, Err: ErrUnverifiedSignature}
+	}
+	if err != nil {
+		return err
+	}
+
+	err = remote.PushContext(ctx, &git.PushOptions{
+		RemoteName: remote.Config().Name,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       auth,
+	})
+
+	if err == transport.ErrRepositoryNotFound {
+		client, err := dgit.Default()
+		if err != nil {
+			return err
+		}
+
+		_, err = client.CreateRepoTree(ctx, endpoint, auth, nil)
+		if err != nil {
+			return err
+		}
+
+		// Retry push now that repo exists
+		err = remote.PushContext(ctx, &git.PushOptions{
+			RemoteName: remote.Config().Name,
+			RefSpecs:   []config.RefSpec{refSpec},
+			Auth:       auth,
+		})
+		if err != nil && err != git.NoErrAlreadyUpToDate {
+			return &PushError{Ref: dst, Err: err}
+		}
+		return nil
+	}
+
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return &PushError{Ref: dst, Err: err}
+	}
+
+	return nil
+}
+
+// checkFastForward verifies that a non-forced push only adds commits on top
+// of the ref currently stored in the repo chaintree
+func (r *Runner) checkFastForward(remote *git.Remote, refSpec config.RefSpec) error {
+	if refSpec.IsForceUpdate() || refSpec.IsDelete() {
+		return nil
+	}
+
+	remoteRefs, err := remote.List(&git.ListOptions{})
+	if err == transport.ErrRepositoryNotFound || err == transport.ErrEmptyRemoteRepository {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return r.checkFastForwardRefs(refSpec, remoteRefs)
+}
+
+func (r *Runner) checkFastForwardRefs(refSpec config.RefSpec, remoteRefs []*plumbing.Reference) error {
+	dst := refSpec.Dst(plumbing.ReferenceName("*"))
+
+	var old *plumbing.Reference
+	for _, ref := range remoteRefs {
+		if ref.Name() == dst {
+			old = ref
+		}
+	}
+	if old == nil || old.Type() != plumbing.HashReference {
+		return nil
+	}
+
+	new, err := r.local.Reference(plumbing.ReferenceName(refSpec.Src()), true)
+	if err != nil {
+		return err
+	}
+
+	ff, err := storage.IsFastForward(r.local.Storer, old.Hash(), new.Hash())
+	// the remote ref points to objects not present locally, so the push
+	// would discard them
+	if err == plumbing.ErrObjectNotFound || err == object.ErrUnsupportedObject {
+		return ErrNonFastForward
+	}
+	if err != nil {
+		return err
+	}
+
+	if !ff {
+		log.Infof("rejecting non-fast-forward push of %s from %s to %s", dst, old.Hash(), new.Hash())
+		return ErrNonFastForward
+	}
+
+	return nil
+}
+
+// checkSignatures verifies that every commit or tag the push adds is signed
+// by its author's profile key, if decentragit.verifySignatures is
+// set. Pushes creating the repo aren't checked since it has no
+// collaborators yet.
+func (r *Runner) checkSignatures(ctx context.Context, remote *git.Remote, endpoint *transport.Endpoint, refSpec config.RefSpec) error {
+	if refSpec.IsDelete() {
+		return nil
+	}
+
+	repoConfig, err := r.local.Config()
+	if err != nil {
+		return err
+	}
+	dgitConfig := repoConfig.Merged.Section(constants.DgitConfigSection)
+	if dgitConfig == nil || dgitConfig.Option("verifySignatures") != "true" {
+		return nil
+	}
+
+	remoteRefs, err := remote.List(&git.ListOptions{})
+	if err == transport.ErrRepositoryNotFound {
+		return nil
+	}
+	if err != nil && err != transport.ErrEmptyRemoteRepository {
+		return err
+	}
+
+	known := make([]plumbing.Hash, 0, len(remoteRefs))
+	for _, ref := range remoteRefs {
+		if ref.Type() == plumbing.HashReference {
+			known = append(known, ref.Hash())
+		}
+	}
+
+	if r.verifier == nil {
+		client, err := dgit.Default()
+		if err != nil {
+			return err
+		}
+		r.verifier, err = client.NewVerifier(ctx, endpoint.Host+endpoint.Path)
+		if err != nil {
+			return err
+		}
+	}
+
+	ref, err := r.local.Reference(plumbing.ReferenceName(refSpec.Src()), true)
+	if err != nil {
+		return err
+	}
+
+	obj, err := r.local.Object(plumbing.AnyObject, ref.Hash())
+	if err != nil {
+		return err
+	}
+
+	var tip *object.Commit
+	switch o := obj.(type) {
+	case *object.Tag:
+		if verification := r.verifier.VerifyTag(o); verification.Err != nil {
+			return fmt.Errorf("%w: tag %s", ErrUnverifiedSignature, verification)
+		}
+		tip, err = o.Commit()
+		if err == object.ErrUnsupportedObject {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	case *object.Commit:
+		tip = o
+	default:
+		return nil
+	}
+
+	verifications, err := r.verifier.VerifyNewCommits(r.local.Storer, tip, known)
+	if err != nil {
+		return err
+	}
+
+	for _, verification := range verifications {
+		if verification.Err != nil {
+			return fmt.Errorf("%w: commit %s", ErrUnverifiedSignature, verification)
+		}
+		log.Debugf("verified %s", verification)
+	}
+
+	return nil
+}
+
 func (r *Runner) respond(format string, a ...interface{}) (n int, err error) {
 	log.Infof("responding to git:")
 	resp := bufio.NewScanner(strings.NewReader(fmt.Sprintf(format, a...)))
@@ -296,30 +549,18 @@ func (r *Runner) auth() (transport.AuthMethod, error) {
 		return nil, err
 	}
 
-	dgitConfig := repoConfig.Merged.Section(constants.DgitConfigSection)
-
-	var username string
-	if dgitConfig != nil {
-		username = dgitConfig.Option("username")
-	}
-
-	envUsername := os.Getenv("DGIT_USERNAME")
-	if envUsername != "" {
-		log.Warningf("[DEPRECATION] - DGIT_USERNAME is deprecated, please use DG_USERNAME")
-		username = envUsername
-	}
-	envUsername = os.Getenv("DG_USERNAME")
-	if envUsername != "" {
-		username = envUsername
-	}
-
-	if username == "" {
+	identity, err := dgit.ResolveIdentity(repoConfig, r.remoteName, r.remoteUrl)
+	if err == dgit.ErrNoIdentity {
 		return nil, fmt.Errorf(msg.Parse(msg.UserNotConfigured, map[string]interface{}{
 			"configSection": constants.DgitConfigSection,
 		}))
 	}
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("using identity %s from %s", identity.Username, identity.Reason)
 
-	privateKey, err := r.keyring.FindPrivateKey(username)
+	privateKey, err := r.keyring.FindPrivateKey(identity.Username)
 	if err == keyring.ErrKeyNotFound {
 		return nil, fmt.Errorf(msg.Parse(msg.PrivateKeyNotFound, map[string]interface{}{
 			"keyringProvider": r.keyring.Name(),
@@ -14,6 +14,7 @@ import (
 	fixtures "github.com/go-git/go-git-fixtures/v4"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/cache"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/storage/filesystem"
@@ -110,6 +111,7 @@ func TestRunnerIntegration(t *testing.T) {
 
 		gitOutputReader.Expect(t, "*push\n")
 		gitOutputReader.Expect(t, "*fetch\n")
+		gitOutputReader.Expect(t, "option\n")
 		gitOutputReader.Expect(t, "\n")
 	})
 
@@ -188,6 +190,27 @@ func TestRunnerIntegration(t *testing.T) {
 	})
 }
 
+func TestCheckFastForwardRefs(t *testing.T) {
+	defer fixtures.Clean()
+
+	store := filesystem.NewStorage(fixtures.Basic().One().DotGit(), cache.NewObjectLRUDefault())
+	local, err := git.Open(store, nil)
+	require.Nil(t, err)
+
+	runner := &Runner{local: local}
+
+	remoteRefs := []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/master", plumbing.NewHash("918c48b83bd081e863dbe1b80f8998f058cd8294")),
+		plumbing.NewHashReference("refs/heads/diverged", plumbing.NewHash("e8d3ffab552895c19b9fcf7aa264d277cde33881")),
+		plumbing.NewHashReference("refs/heads/unknown", plumbing.NewHash("0000000000000000000000000000000000000001")),
+	}
+
+	require.Nil(t, runner.checkFastForwardRefs(config.RefSpec("refs/heads/master:refs/heads/master"), remoteRefs))
+	require.Nil(t, runner.checkFastForwardRefs(config.RefSpec("refs/heads/master:refs/heads/new"), remoteRefs))
+	require.Equal(t, ErrNonFastForward, runner.checkFastForwardRefs(config.RefSpec("refs/heads/master:refs/heads/diverged"), remoteRefs))
+	require.Equal(t, ErrNonFastForward, runner.checkFastForwardRefs(config.RefSpec("refs/heads/master:refs/heads/unknown"), remoteRefs))
+}
+
 type testOutputReader struct {
 	*bufio.Reader
 }
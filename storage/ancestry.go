@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// IsFastForward walks the commits reachable from new and returns true if
+// old is one of them. Objects missing from s are reported as errors.
+func IsFastForward(s storer.EncodedObjectStorer, old, new plumbing.Hash) (bool, error) {
+	if old == new {
+		return true, nil
+	}
+
+	c, err := object.GetCommit(s, new)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	iter := object.NewCommitPreorderIter(c, nil, nil)
+	err = iter.ForEach(func(c *object.Commit) error {
+		if c.Hash != old {
+			return nil
+		}
+
+		found = true
+		return storer.ErrStop
+	})
+	return found, err
+}
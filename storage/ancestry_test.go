@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"testing"
+
+	fixtures "github.com/go-git/go-git-fixtures/v4"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsFastForward(t *testing.T) {
+	defer fixtures.Clean()
+
+	s := filesystem.NewStorage(fixtures.Basic().One().DotGit(), cache.NewObjectLRUDefault())
+
+	master := plumbing.NewHash("6ecf0ef2c2dffb796033e5a02219af86ec6584e5")
+	masterParent := plumbing.NewHash("918c48b83bd081e863dbe1b80f8998f058cd8294")
+	branch := plumbing.NewHash("e8d3ffab552895c19b9fcf7aa264d277cde33881")
+
+	ff, err := IsFastForward(s, masterParent, master)
+	require.Nil(t, err)
+	require.True(t, ff)
+
+	ff, err = IsFastForward(s, master, masterParent)
+	require.Nil(t, err)
+	require.False(t, ff)
+
+	ff, err = IsFastForward(s, master, branch)
+	require.Nil(t, err)
+	require.False(t, ff)
+}
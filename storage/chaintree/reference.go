@@ -2,9 +2,12 @@ package chaintree
 
 import (
 	"context"
+	"errors"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/storer"
 	gitstorage "github.com/go-git/go-git/v5/storage"
@@ -22,6 +25,10 @@ type ReferenceStorage struct {
 
 var _ storer.ReferenceStorer = (*ReferenceStorage)(nil)
 
+var RepoArchivedPath = append(append([]string{}, RepoConfigPath...), "archived")
+
+var ErrRepoArchived = errors.New("repo is archived and read-only")
+
 func NewReferenceStorage(config *storage.Config) storer.ReferenceStorer {
 	did := config.ChainTree.MustId()
 	return &ReferenceStorage{
@@ -32,16 +39,65 @@ func NewReferenceStorage(config *storage.Config) storer.ReferenceStorer {
 
 func (s *ReferenceStorage) SetReference(ref *plumbing.Reference) error {
 	log.Debugf("set reference %s to %s", ref.Name().String(), ref.Hash().String())
-	return s.setData(ref.Name().String(), ref.Hash().String())
+	old, err := s.checkReference(ref.Name(), ref)
+	if err != nil {
+		return err
+	}
+	return s.setData(ref.Name(), old, ref)
+}
+
+// checkReference returns the current value of the reference once the update
+// to ref is allowed
+func (s *ReferenceStorage) checkReference(name plumbing.ReferenceName, ref *plumbing.Reference) (*plumbing.Reference, error) {
+	archivedUncast, _, err := s.ChainTree.ChainTree.Dag.Resolve(context.Background(), RepoArchivedPath)
+	if err != nil {
+		return nil, err
+	}
+	if archived, ok := archivedUncast.(bool); ok && archived {
+		return nil, ErrRepoArchived
+	}
+
+	old, err := s.Reference(name)
+	if err == plumbing.ErrReferenceNotFound {
+		old, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.ReferenceGuard == nil {
+		return old, nil
+	}
+
+	return old, s.ReferenceGuard.CheckReference(name, old, ref)
 }
 
-func (s *ReferenceStorage) setData(key string, val interface{}) error {
-	txn, err := chaintree.NewSetDataTransaction(key, val)
+func (s *ReferenceStorage) setData(name plumbing.ReferenceName, old *plumbing.Reference, new *plumbing.Reference) error {
+	var val interface{}
+	if new != nil {
+		val = new.Hash().String()
+	}
+
+	txn, err := chaintree.NewSetDataTransaction(name.String(), val)
 	if err != nil {
 		return err
 	}
 
-	_, err = s.Tupelo.PlayTransactions(s.Ctx, s.ChainTree, s.PrivateKey, []*transactions.Transaction{txn})
+	now := time.Now()
+
+	// updatedAt tracks the last ref change, alongside the createdAt set
+	// when the tree was created
+	updatedAtTxn, err := chaintree.NewSetDataTransaction("updatedAt", now.Unix())
+	if err != nil {
+		return err
+	}
+
+	certTxn, err := s.pushCertificateTxn(name, old, new, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Tupelo.PlayTransactions(s.Ctx, s.ChainTree, s.PrivateKey, []*transactions.Transaction{txn, updatedAtTxn, certTxn})
 	if err != nil {
 		return err
 	}
@@ -49,6 +105,32 @@ func (s *ReferenceStorage) setData(key string, val interface{}) error {
 	return nil
 }
 
+// pushCertificateTxn appends the certificate of a reference update to the
+// ref's push history
+func (s *ReferenceStorage) pushCertificateTxn(name plumbing.ReferenceName, old *plumbing.Reference, new *plumbing.Reference, now time.Time) (*transactions.Transaction, error) {
+	cert := &storage.PushCertificate{
+		Address:   crypto.PubkeyToAddress(s.PrivateKey.PublicKey).String(),
+		Timestamp: now,
+		Options:   s.PushOptions,
+	}
+	if old != nil {
+		cert.Old = old.Hash().String()
+	}
+	if new != nil {
+		cert.New = new.Hash().String()
+	}
+	if s.Pusher != nil {
+		pusher, err := s.Pusher.PusherDid()
+		if err != nil {
+			return nil, err
+		}
+		cert.Pusher = pusher
+	}
+
+	certPath := append(storage.PushHistoryPath(name), storage.PushCertificateKey(cert))
+	return chaintree.NewSetDataTransaction(strings.Join(certPath, "/"), cert.ToMap())
+}
+
 func (s *ReferenceStorage) CheckAndSetReference(ref *plumbing.Reference, old *plumbing.Reference) error {
 	if ref == nil {
 		return nil
@@ -92,7 +174,11 @@ func (s *ReferenceStorage) Reference(n plumbing.ReferenceName) (*plumbing.Refere
 }
 
 func (s *ReferenceStorage) RemoveReference(n plumbing.ReferenceName) error {
-	return s.setData(n.String(), nil)
+	old, err := s.checkReference(n, nil)
+	if err != nil {
+		return err
+	}
+	return s.setData(n, old, nil)
 }
 
 func (s *ReferenceStorage) CountLooseRefs() (int, error) {
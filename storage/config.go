@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/ecdsa"
 
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/quorumcontrol/tupelo/sdk/consensus"
 	tupelo "github.com/quorumcontrol/tupelo/sdk/gossip/client"
 )
@@ -13,4 +14,24 @@ type Config struct {
 	Tupelo     *tupelo.Client
 	ChainTree  *consensus.SignedChainTree
 	PrivateKey *ecdsa.PrivateKey
+	// ReferenceGuard is optional, when set it is consulted before any
+	// reference is written or removed
+	ReferenceGuard ReferenceGuard
+	// Pusher is optional, when set the did it returns is recorded in the
+	// push certificate of every reference update
+	Pusher Pusher
+	// PushOptions are recorded in the push certificate of every reference
+	// update, as given with git push --push-option
+	PushOptions []string
+}
+
+// ReferenceGuard decides whether a reference update is allowed. old is nil
+// when the reference doesn't exist yet and new is nil when it is being removed.
+type ReferenceGuard interface {
+	CheckReference(name plumbing.ReferenceName, old *plumbing.Reference, new *plumbing.Reference) error
+}
+
+// Pusher resolves the did of the user making reference updates
+type Pusher interface {
+	PusherDid() (string, error)
 }
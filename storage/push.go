@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// PushesPath is where push certificates are kept in the repo chaintree data,
+// one map of certificates per ref
+var PushesPath = []string{"pushes"}
+
+// PushCertificate records a single reference update: who made it, what it
+// changed and the push options it was made with. Certificates are written
+// in the same block as the update, so they are notarized along with it.
+type PushCertificate struct {
+	// Pusher is the did of the user tree owned by the key that signed the
+	// update, it is empty if none of the repo's collaborators own the key
+	Pusher    string    `json:"pusher,omitempty"`
+	Address   string    `json:"address"`
+	Old       string    `json:"old,omitempty"`
+	New       string    `json:"new,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Options   []string  `json:"options,omitempty"`
+}
+
+// PushHistoryPath returns the path of the certificates of the given ref,
+// relative to the chaintree data. Ref names are escaped into a single key
+// so that histories don't nest like the refs themselves do.
+func PushHistoryPath(name plumbing.ReferenceName) []string {
+	return append(append([]string{}, PushesPath...), url.PathEscape(name.String()))
+}
+
+// PushCertificateKey returns the key of a certificate in its ref's push
+// history. Keys sort in the order certificates were written and are unique
+// to the certificate so that concurrent pushes of a ref can't overwrite each
+// other's certificate.
+func PushCertificateKey(c *PushCertificate) string {
+	digest := sha256.Sum256([]byte(strings.Join([]string{c.Pusher, c.Address, c.Old, c.New}, "\n")))
+	return fmt.Sprintf("%019d-%x", c.Timestamp.UnixNano(), digest[:4])
+}
+
+func (c *PushCertificate) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"address":   c.Address,
+		"timestamp": c.Timestamp.Unix(),
+	}
+	if c.Pusher != "" {
+		m["pusher"] = c.Pusher
+	}
+	if c.Old != "" {
+		m["old"] = c.Old
+	}
+	if c.New != "" {
+		m["new"] = c.New
+	}
+	if len(c.Options) > 0 {
+		m["options"] = c.Options
+	}
+	return m
+}
+
+func PushCertificateFromMap(m map[string]interface{}) (*PushCertificate, error) {
+	c := &PushCertificate{}
+
+	for key, dst := range map[string]*string{
+		"pusher":  &c.Pusher,
+		"address": &c.Address,
+		"old":     &c.Old,
+		"new":     &c.New,
+	} {
+		switch val := m[key].(type) {
+		case nil:
+		case string:
+			*dst = val
+		default:
+			return nil, fmt.Errorf("push certificate %s is %T, expected string", key, val)
+		}
+	}
+
+	switch val := m["timestamp"].(type) {
+	case int64:
+		c.Timestamp = time.Unix(val, 0)
+	case uint64:
+		c.Timestamp = time.Unix(int64(val), 0)
+	case int:
+		c.Timestamp = time.Unix(int64(val), 0)
+	default:
+		return nil, fmt.Errorf("push certificate timestamp is %T, expected a unix timestamp", val)
+	}
+
+	switch val := m["options"].(type) {
+	case nil:
+	case []string:
+		c.Options = val
+	case []interface{}:
+		for _, opt := range val {
+			optStr, ok := opt.(string)
+			if !ok {
+				return nil, fmt.Errorf("push certificate option is %T, expected string", opt)
+			}
+			c.Options = append(c.Options, optStr)
+		}
+	default:
+		return nil, fmt.Errorf("push certificate options are %T, expected a list", val)
+	}
+
+	return c, nil
+}
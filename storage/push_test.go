@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushCertificateMap(t *testing.T) {
+	cert := &PushCertificate{
+		Pusher:    "did:tupelo:0x1234",
+		Address:   "0xabcd",
+		New:       "6ecf0ef2c2dffb796033e5a02219af86ec6584e5",
+		Timestamp: time.Unix(1600000000, 0),
+		Options:   []string{"ci.skip", "reviewer=alice"},
+	}
+
+	m := cert.ToMap()
+	require.NotContains(t, m, "old")
+
+	// options come back from the chaintree as a generic list
+	m["options"] = []interface{}{"ci.skip", "reviewer=alice"}
+
+	decoded, err := PushCertificateFromMap(m)
+	require.Nil(t, err)
+	require.Equal(t, cert, decoded)
+
+	_, err = PushCertificateFromMap(map[string]interface{}{"address": "0xabcd"})
+	require.NotNil(t, err)
+}
+
+func TestPushHistoryPath(t *testing.T) {
+	require.Equal(t, []string{"pushes", "refs%2Fheads%2Frelease%2Fv1"}, PushHistoryPath(plumbing.ReferenceName("refs/heads/release/v1")))
+}
+
+func TestPushCertificateKey(t *testing.T) {
+	first := &PushCertificate{Address: "0xabcd", New: "6ecf0ef2c2dffb796033e5a02219af86ec6584e5", Timestamp: time.Unix(1600000000, 0)}
+	later := &PushCertificate{Address: "0xabcd", New: "918c48b83bd081e863dbe1b80f8998f058cd8294", Timestamp: time.Unix(1600000000, 1)}
+	require.True(t, PushCertificateKey(first) < PushCertificateKey(later))
+
+	// certificates of concurrent pushes get their own keys
+	concurrent := &PushCertificate{Address: "0x1234", New: first.New, Timestamp: first.Timestamp}
+	require.NotEqual(t, PushCertificateKey(first), PushCertificateKey(concurrent))
+}
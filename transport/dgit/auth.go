@@ -12,6 +12,10 @@ const PrivateKeyAuthName = "private-key-auth"
 type PrivateKeyAuth struct {
 	transport.AuthMethod
 	key *ecdsa.PrivateKey
+
+	// PushOptions are recorded in the push certificates of the refs updated
+	// with this auth
+	PushOptions []string
 }
 
 func NewPrivateKeyAuth(key *ecdsa.PrivateKey) *PrivateKeyAuth {
@@ -2,8 +2,11 @@ package dgit
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"path"
+	"sort"
+	"strings"
 
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
@@ -14,8 +17,10 @@ import (
 
 	"github.com/quorumcontrol/dgit/constants"
 	"github.com/quorumcontrol/dgit/tupelo/clientbuilder"
+	"github.com/quorumcontrol/dgit/tupelo/orgtree"
 	"github.com/quorumcontrol/dgit/tupelo/repotree"
 	"github.com/quorumcontrol/dgit/tupelo/teamtree"
+	"github.com/quorumcontrol/dgit/tupelo/tree"
 	"github.com/quorumcontrol/dgit/tupelo/usertree"
 )
 
@@ -65,7 +70,7 @@ func NewLocalClient(ctx context.Context) (*Client, error) {
 }
 
 // FIXME: this probably shouldn't be here
-func (c *Client) CreateRepoTree(ctx context.Context, endpoint *transport.Endpoint, auth transport.AuthMethod) (*repotree.RepoTree, error) {
+func (c *Client) CreateRepoTree(ctx context.Context, endpoint *transport.Endpoint, auth transport.AuthMethod, metadata *repotree.Metadata) (*repotree.RepoTree, error) {
 	var (
 		pkAuth *PrivateKeyAuth
 		ok     bool
@@ -74,8 +79,9 @@ func (c *Client) CreateRepoTree(ctx context.Context, endpoint *transport.Endpoin
 		return nil, fmt.Errorf("unable to cast %T to PrivateKeyAuth", auth)
 	}
 	return repotree.Create(ctx, &repotree.Options{
-		Name:   endpoint.Host + endpoint.Path,
-		Tupelo: c.Tupelo,
+		Name:     endpoint.Host + endpoint.Path,
+		Tupelo:   c.Tupelo,
+		Metadata: metadata,
 	}, pkAuth.Key())
 }
 
@@ -97,52 +103,150 @@ func (c *Client) NewReceivePackSession(ep *transport.Endpoint, auth transport.Au
 	return server.NewServer(loader).NewReceivePackSession(ep, auth)
 }
 
-func (c *Client) AddRepoCollaborator(ctx context.Context, repo *Repo, collaborators []string) error {
-	repoName, err := repo.Name()
-	if err != nil {
-		return err
+// TeamRefPrefix marks a collaborator as a team rather than a user: "@org"
+// for an org's team, or "@owner/repo:team" for a team of another repo
+const TeamRefPrefix = "@"
+
+func IsTeamRef(collaborator string) bool {
+	return strings.HasPrefix(collaborator, TeamRefPrefix)
+}
+
+// FindTeamRef returns the team a team reference like "@org" or
+// "@owner/repo:team" refers to
+func (c *Client) FindTeamRef(ctx context.Context, ref string) (*teamtree.TeamTree, error) {
+	name := strings.TrimPrefix(ref, TeamRefPrefix)
+
+	if !strings.Contains(name, "/") {
+		org, err := orgtree.Find(ctx, name, c.Tupelo)
+		if err == orgtree.ErrNotFound {
+			return nil, fmt.Errorf("Org %s not found", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return org.Team(ctx)
 	}
 
-	repoTree, err := c.FindRepoTree(ctx, repoName)
-	if err != nil {
-		return err
+	parts := strings.SplitN(name, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid team %s, expected @org or @owner/repo:team", ref)
 	}
 
-	auth, err := repo.Auth()
+	repoTree, err := c.FindRepoTree(ctx, parts[0])
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var (
-		pkAuth *PrivateKeyAuth
-		ok     bool
-	)
-	if pkAuth, ok = auth.(*PrivateKeyAuth); !ok {
-		return fmt.Errorf("auth is not castable to PrivateKeyAuth; was a %T", auth)
-	}
+	return repoTree.Team(ctx, parts[1])
+}
 
-	team, err := repoTree.Team(ctx, "default")
+// AddRepoCollaborator invites collaborators to the named team, creating it
+// if needed. Users are added once they accept with the returned codes,
+// teams (see IsTeamRef) are included right away.
+func (c *Client) AddRepoCollaborator(ctx context.Context, repo *Repo, teamName string, role repotree.Role, collaborators []string) ([]*teamtree.InviteCode, error) {
+	repoTree, key, err := c.repoTreeAndKey(ctx, repo)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	members := make(teamtree.Members, len(collaborators))
-	for i, username := range collaborators {
-		user, err := usertree.Find(ctx, username, c.Tupelo)
+	members := teamtree.Members{}
+	teams := teamtree.Members{}
+	for _, collaborator := range collaborators {
+		if IsTeamRef(collaborator) {
+			team, err := c.FindTeamRef(ctx, collaborator)
+			if err != nil {
+				return nil, err
+			}
+			teams = append(teams, teamtree.NewMember(team.Did(), collaborator))
+			continue
+		}
+
+		user, err := usertree.Find(ctx, collaborator, c.Tupelo)
 		if err == usertree.ErrNotFound {
-			return fmt.Errorf("User %s not found", username)
+			return nil, fmt.Errorf("User %s not found", collaborator)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, teamtree.NewMember(user.Did(), collaborator))
+	}
+
+	_, err = repoTree.Team(ctx, teamName)
+	if err == teamtree.ErrNotFound {
+		if role == repotree.RoleNone {
+			role = repotree.RoleWrite
+		}
+		_, err = repoTree.AddTeam(ctx, key, teamName, role, teamtree.Members{})
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if role != repotree.RoleNone {
+		// adding members never changes what an existing team grants,
+		// that takes SetRepoTeamRole
+		current, err := teamRole(ctx, repoTree, teamName)
+		if err != nil {
+			return nil, err
 		}
+		if current != role {
+			return nil, fmt.Errorf("team %s has the %s role, not %s, use team set-role to change it", teamName, current, role)
+		}
+	}
+
+	if len(teams) > 0 {
+		err = repoTree.AddTeamMemberTeams(ctx, key, teamName, teams)
 		if err != nil {
-			return err
+			return nil, err
+		}
+	}
+
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	return repoTree.InviteTeamMembers(ctx, key, teamName, members)
+}
+
+// SetRepoTeamRole changes the role granted by an existing team of the repo
+func (c *Client) SetRepoTeamRole(ctx context.Context, repo *Repo, teamName string, role repotree.Role) error {
+	repoTree, key, err := c.repoTreeAndKey(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	return repoTree.SetTeamRole(ctx, key, teamName, role)
+}
+
+func teamRole(ctx context.Context, repoTree *repotree.RepoTree, teamName string) (repotree.Role, error) {
+	teams, err := repoTree.Teams(ctx)
+	if err != nil {
+		return repotree.RoleNone, err
+	}
+	for _, team := range teams {
+		if team.Name == teamName {
+			return team.Role, nil
 		}
+	}
+	return repotree.RoleNone, teamtree.ErrNotFound
+}
 
-		members[i] = teamtree.NewMember(user.Did(), username)
+func (c *Client) ListRepoTeams(ctx context.Context, repo *Repo) ([]*repotree.Team, error) {
+	repoName, err := repo.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	repoTree, err := c.FindRepoTree(ctx, repoName)
+	if err != nil {
+		return nil, err
 	}
 
-	return team.AddMembers(ctx, pkAuth.Key(), members)
+	return repoTree.Teams(ctx)
 }
 
-func (c *Client) ListRepoCollaborators(ctx context.Context, repo *Repo) ([]string, error) {
+func (c *Client) ListRepoCollaborators(ctx context.Context, repo *Repo, teamName string) ([]string, error) {
 	repoName, err := repo.Name()
 	if err != nil {
 		return []string{}, err
@@ -153,7 +257,7 @@ func (c *Client) ListRepoCollaborators(ctx context.Context, repo *Repo) ([]strin
 		return []string{}, err
 	}
 
-	team, err := repoTree.Team(ctx, "default")
+	team, err := repoTree.Team(ctx, teamName)
 	if err != nil {
 		return []string{}, err
 	}
@@ -166,20 +270,56 @@ func (c *Client) ListRepoCollaborators(ctx context.Context, repo *Repo) ([]strin
 	return members.Names(), nil
 }
 
-func (c *Client) RemoveRepoCollaborator(ctx context.Context, repo *Repo, collaborators []string) error {
+// PlanRemoveRepoCollaborator previews removing collaborators from the named
+// team without changing it
+func (c *Client) PlanRemoveRepoCollaborator(ctx context.Context, repo *Repo, teamName string, collaborators []string) (*teamtree.Removal, error) {
 	repoName, err := repo.Name()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	repoTree, err := c.FindRepoTree(ctx, repoName)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return repoTree.PlanTeamRemoval(ctx, teamName, collaborators)
+}
+
+// RemoveRepoCollaborator removes collaborators from the named team, or
+// revokes their invitation if they haven't accepted yet
+func (c *Client) RemoveRepoCollaborator(ctx context.Context, repo *Repo, teamName string, collaborators []string) (*teamtree.Removal, error) {
+	repoTree, key, err := c.repoTreeAndKey(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return repoTree.RemoveTeamMembers(ctx, key, teamName, collaborators)
+}
+
+func (c *Client) repoTreeAndKey(ctx context.Context, repo *Repo) (*repotree.RepoTree, *ecdsa.PrivateKey, error) {
+	repoName, err := repo.Name()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repoTree, err := c.FindRepoTree(ctx, repoName)
+	if err != nil {
+		return nil, nil, err
 	}
 
+	key, err := authKey(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repoTree, key, nil
+}
+
+func authKey(repo *Repo) (*ecdsa.PrivateKey, error) {
 	auth, err := repo.Auth()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var (
@@ -187,23 +327,149 @@ func (c *Client) RemoveRepoCollaborator(ctx context.Context, repo *Repo, collabo
 		ok     bool
 	)
 	if pkAuth, ok = auth.(*PrivateKeyAuth); !ok {
-		return fmt.Errorf("auth is not castable to PrivateKeyAuth; was a %T", auth)
+		return nil, fmt.Errorf("auth is not castable to PrivateKeyAuth; was a %T", auth)
+	}
+
+	return pkAuth.Key(), nil
+}
+
+func (c *Client) ListProtectionRules(ctx context.Context, repo *Repo) ([]*repotree.ProtectionRule, error) {
+	repoName, err := repo.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	repoTree, err := c.FindRepoTree(ctx, repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	return repoTree.ProtectionRules(ctx)
+}
+
+func (c *Client) ProtectRef(ctx context.Context, repo *Repo, rule *repotree.ProtectionRule) error {
+	repoTree, key, err := c.repoTreeAndKey(ctx, repo)
+	if err != nil {
+		return err
 	}
 
-	team, err := repoTree.Team(ctx, "default")
+	return repoTree.SetProtectionRule(ctx, key, rule)
+}
+
+func (c *Client) UnprotectRef(ctx context.Context, repo *Repo, pattern string) error {
+	repoTree, key, err := c.repoTreeAndKey(ctx, repo)
 	if err != nil {
 		return err
 	}
 
-	members := make(teamtree.Members, len(collaborators))
-	for i, username := range collaborators {
-		did, err := usertree.Did(username)
+	return repoTree.RemoveProtectionRule(ctx, key, pattern)
+}
+
+// MoveRepo renames the repo or moves it to another user or org the current
+// user manages, to is the full new repo name
+func (c *Client) MoveRepo(ctx context.Context, repo *Repo, to string) (*repotree.RepoTree, error) {
+	repoName, err := repo.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := authKey(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return repotree.Move(ctx, c.Tupelo, repoName, to, key)
+}
+
+// ForkRepo forks the full repo name parent into the current user's
+// namespace
+func (c *Client) ForkRepo(ctx context.Context, repo *Repo, parent string) (*repotree.RepoTree, error) {
+	key, err := authKey(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := repo.Username()
+	if err != nil {
+		return nil, err
+	}
+
+	parentTree, err := c.FindRepoTree(ctx, parent)
+	if err == repotree.ErrNotFound {
+		return nil, fmt.Errorf("repo %s not found (%w)", parent, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(parent, "/")
+	name := username + "/" + parts[len(parts)-1]
+
+	return repotree.Fork(ctx, parentTree, name, key)
+}
+
+func (c *Client) ArchiveRepo(ctx context.Context, repo *Repo) error {
+	repoTree, key, err := c.repoTreeAndKey(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	return repoTree.Archive(ctx, key)
+}
+
+func (c *Client) DeleteRepo(ctx context.Context, repo *Repo) error {
+	repoName, err := repo.Name()
+	if err != nil {
+		return err
+	}
+
+	key, err := authKey(repo)
+	if err != nil {
+		return err
+	}
+
+	return repotree.Delete(ctx, c.Tupelo, repoName, key)
+}
+
+func (c *Client) SetRepoMetadata(ctx context.Context, repo *Repo, metadata *repotree.Metadata) error {
+	repoTree, key, err := c.repoTreeAndKey(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	return repoTree.SetMetadata(ctx, key, metadata)
+}
+
+// ListRepos summarizes the repos of the named user or org, sorted by name
+func (c *Client) ListRepos(ctx context.Context, ownerName string) ([]*repotree.Summary, error) {
+	owner, err := repotree.FindOwner(ctx, ownerName, c.Tupelo)
+	if err != nil {
+		return nil, err
+	}
+
+	repos, err := owner.Repos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(repos))
+	for name := range repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summaries := make([]*repotree.Summary, len(names))
+	for i, name := range names {
+		t, err := tree.Find(ctx, c.Tupelo, repos[name])
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("error finding repo %s/%s: %w", ownerName, name, err)
 		}
 
-		members[i] = teamtree.NewMember(did, username)
+		summaries[i], err = (&repotree.RepoTree{Tree: t}).Summary(ctx, ownerName+"/"+name)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return team.RemoveMembers(ctx, pkAuth.Key(), members)
+	return summaries, nil
 }
@@ -0,0 +1,137 @@
+package dgit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/quorumcontrol/dgit/keyring"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+var ErrDeviceAlreadyAuthorized = errors.New("this machine is already authorized")
+
+// NewDevicePairingCode generates a key for this machine, unless one is
+// already waiting to be paired, and returns the code an authorized device
+// needs to add it to the user
+func (c *Client) NewDevicePairingCode(ctx context.Context, repo *Repo, deviceName string) (*usertree.PairingCode, error) {
+	if err := usertree.ValidateDeviceName(deviceName); err != nil {
+		return nil, err
+	}
+
+	username, err := repo.Username()
+	if err != nil {
+		return nil, err
+	}
+
+	userTree, err := usertree.Find(ctx, username, c.Tupelo)
+	if err != nil {
+		return nil, err
+	}
+
+	kr, err := keyring.NewDefault()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := kr.FindPrivateKey(username)
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		key, err = crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		err = kr.SetPrivateKey(username, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	addr := crypto.PubkeyToAddress(key.PublicKey).String()
+
+	isOwner, err := userTree.IsOwner(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	if isOwner {
+		return nil, ErrDeviceAlreadyAuthorized
+	}
+
+	return &usertree.PairingCode{
+		Username: username,
+		Device:   deviceName,
+		Address:  addr,
+	}, nil
+}
+
+// AddDevice authorizes the device of the pairing code to act as the
+// current user
+func (c *Client) AddDevice(ctx context.Context, repo *Repo, code *usertree.PairingCode) error {
+	userTree, key, err := c.userTreeAndKey(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	if code.Username != userTree.Name() {
+		return fmt.Errorf("pairing code is for user %s, but the current user is %s", code.Username, userTree.Name())
+	}
+
+	return userTree.AddDevice(ctx, key, &usertree.Device{
+		Name:    code.Device,
+		Address: code.Address,
+	})
+}
+
+func (c *Client) ListDevices(ctx context.Context, repo *Repo) ([]*usertree.Device, error) {
+	username, err := repo.Username()
+	if err != nil {
+		return nil, err
+	}
+
+	userTree, err := usertree.Find(ctx, username, c.Tupelo)
+	if err != nil {
+		return nil, err
+	}
+
+	return userTree.Devices(ctx)
+}
+
+func (c *Client) RevokeDevice(ctx context.Context, repo *Repo, name string) (*usertree.Device, error) {
+	userTree, key, err := c.userTreeAndKey(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return userTree.RevokeDevice(ctx, key, name)
+}
+
+// userTreeAndKey returns the current user's tree, and a key which must be
+// one of its owners
+func (c *Client) userTreeAndKey(ctx context.Context, repo *Repo) (*usertree.UserTree, *ecdsa.PrivateKey, error) {
+	username, err := repo.Username()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := authKey(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userTree, err := usertree.Find(ctx, username, c.Tupelo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isOwner, err := userTree.IsOwner(ctx, crypto.PubkeyToAddress(key.PublicKey).String())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isOwner {
+		return nil, nil, fmt.Errorf("current key is not an owner of user %s", username)
+	}
+
+	return userTree, key, nil
+}
@@ -0,0 +1,87 @@
+package dgit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+
+	"github.com/quorumcontrol/dgit/storage"
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
+)
+
+// repoGuard enforces the repo's team roles and protection rules on
+// reference updates
+type repoGuard struct {
+	ctx      context.Context
+	repoTree *repotree.RepoTree
+	addr     string
+	role     *repotree.Role
+	pusher   *string
+	objects  storer.EncodedObjectStorer
+}
+
+var _ storage.ReferenceGuard = (*repoGuard)(nil)
+var _ storage.Pusher = (*repoGuard)(nil)
+
+func newRepoGuard(ctx context.Context, repoTree *repotree.RepoTree, addr string) *repoGuard {
+	return &repoGuard{
+		ctx:      ctx,
+		repoTree: repoTree,
+		addr:     addr,
+	}
+}
+
+// Role is resolved on first use since walking the repo teams is only
+// needed when something is actually written
+func (g *repoGuard) Role() (repotree.Role, error) {
+	if g.role != nil {
+		return *g.role, nil
+	}
+
+	role, err := g.repoTree.RoleFor(g.ctx, g.addr)
+	if err != nil {
+		return repotree.RoleNone, err
+	}
+	g.role = &role
+
+	return role, nil
+}
+
+// PusherDid returns the member of the repo's teams owned by the pushing key.
+// It is resolved once per push, like Role, rather than for every ref.
+func (g *repoGuard) PusherDid() (string, error) {
+	if g.pusher != nil {
+		return *g.pusher, nil
+	}
+
+	did, err := g.repoTree.MemberFor(g.ctx, g.addr)
+	if err != nil {
+		return "", err
+	}
+	g.pusher = &did
+
+	return did, nil
+}
+
+func (g *repoGuard) CheckReference(name plumbing.ReferenceName, old *plumbing.Reference, new *plumbing.Reference) error {
+	role, err := g.Role()
+	if err != nil {
+		return err
+	}
+
+	if !role.CanWrite() {
+		return fmt.Errorf("%s has %s access to %s, write access is required to update %s", g.addr, role, g.repoTree.Name(), name)
+	}
+
+	return g.repoTree.CheckRefUpdate(g.ctx, &repotree.RefUpdate{
+		Name:   name,
+		Old:    old,
+		New:    new,
+		Signer: g.addr,
+		IsFastForward: func() (bool, error) {
+			return storage.IsFastForward(g.objects, old.Hash(), new.Hash())
+		},
+	})
+}
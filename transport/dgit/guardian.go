@@ -0,0 +1,350 @@
+package dgit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/quorumcontrol/dgit/keyring"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+const (
+	// guardianKeySuffix names the keyring entry which decrypts the
+	// recovery key shares held for other users
+	guardianKeySuffix = ".guardian"
+	// recoveringKeySuffix names the new key of a user being recovered
+	// until enough guardians have approved
+	recoveringKeySuffix = ".recovering"
+)
+
+// ErrNotEnoughApprovals is returned by CompleteRecovery while fewer than
+// the threshold of guardians have approved
+var ErrNotEnoughApprovals = errors.New("not enough guardians have approved the recovery yet")
+
+// EnableGuardian creates this machine's guardian key for the current user
+// and publishes it, so that other users can choose them as a guardian
+func (c *Client) EnableGuardian(ctx context.Context, repo *Repo) error {
+	userTree, key, err := c.userTreeAndKey(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	kr, err := keyring.NewDefault()
+	if err != nil {
+		return err
+	}
+
+	guardianKey, err := kr.FindPrivateKey(userTree.Name() + guardianKeySuffix)
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		guardianKey, err = crypto.GenerateKey()
+		if err != nil {
+			return err
+		}
+		err = kr.SetPrivateKey(userTree.Name()+guardianKeySuffix, guardianKey)
+	}
+	if err != nil {
+		return err
+	}
+
+	published, err := userTree.GuardianPublicKey(ctx)
+	if err != nil && err != usertree.ErrGuardianNotEnabled {
+		return err
+	}
+	if published != nil && crypto.PubkeyToAddress(*published) == crypto.PubkeyToAddress(guardianKey.PublicKey) {
+		return nil
+	}
+
+	return userTree.SetGuardianPublicKey(ctx, key, &guardianKey.PublicKey)
+}
+
+// SetGuardians generates a new recovery key for the current user, and
+// splits it between the guardians so that any threshold of them can
+// recover the user. The recovery key is an owner of the user tree from
+// then on, replacing any previous one.
+func (c *Client) SetGuardians(ctx context.Context, repo *Repo, usernames []string, threshold int) error {
+	userTree, key, err := c.userTreeAndKey(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	return c.setGuardians(ctx, userTree, key, usernames, threshold)
+}
+
+func (c *Client) setGuardians(ctx context.Context, userTree *usertree.UserTree, key *ecdsa.PrivateKey, usernames []string, threshold int) error {
+	recoveryKey, shares, err := keyring.SplitKey(len(usernames), threshold)
+	if err != nil {
+		return err
+	}
+
+	guardians := &usertree.Guardians{
+		Threshold: threshold,
+		Address:   crypto.PubkeyToAddress(recoveryKey.PublicKey).String(),
+		Guardians: make([]*usertree.Guardian, 0, len(usernames)),
+	}
+
+	for i, username := range usernames {
+		if guardians.Find(username) != nil {
+			return fmt.Errorf("guardian %s is listed twice", username)
+		}
+
+		guardianTree, err := usertree.Find(ctx, username, c.Tupelo)
+		if err == usertree.ErrNotFound {
+			return fmt.Errorf("user %s does not exist", username)
+		}
+		if err != nil {
+			return err
+		}
+
+		pub, err := guardianTree.GuardianPublicKey(ctx)
+		if err == usertree.ErrGuardianNotEnabled {
+			return fmt.Errorf("%s has not enabled being a guardian, ask them to run `git dg guardian enable`", username)
+		}
+		if err != nil {
+			return err
+		}
+
+		share, err := keyring.EncryptToKey(pub, shares[i])
+		if err != nil {
+			return err
+		}
+
+		guardians.Guardians = append(guardians.Guardians, &usertree.Guardian{
+			Username: username,
+			Did:      guardianTree.Did(),
+			Share:    share,
+		})
+	}
+
+	return userTree.SetGuardians(ctx, key, guardians)
+}
+
+// rotateRecoveryKey replaces the recovery key of a user with guardians by
+// a new one split between the same guardians, so that shares of the old
+// key are worthless
+func (c *Client) rotateRecoveryKey(ctx context.Context, userTree *usertree.UserTree, key *ecdsa.PrivateKey) error {
+	guardians, err := userTree.Guardians(ctx)
+	if err == usertree.ErrNoGuardians {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	usernames := make([]string, len(guardians.Guardians))
+	for i, guardian := range guardians.Guardians {
+		usernames[i] = guardian.Username
+	}
+
+	return c.setGuardians(ctx, userTree, key, usernames, guardians.Threshold)
+}
+
+func (c *Client) ListGuardians(ctx context.Context, repo *Repo) (*usertree.Guardians, error) {
+	username, err := repo.Username()
+	if err != nil {
+		return nil, err
+	}
+
+	userTree, err := usertree.Find(ctx, username, c.Tupelo)
+	if err != nil {
+		return nil, err
+	}
+
+	return userTree.Guardians(ctx)
+}
+
+// ApproveRecovery re-encrypts the current user's share of the recovery key
+// of the code's user to its new key, and records it in the current user's
+// tree
+func (c *Client) ApproveRecovery(ctx context.Context, repo *Repo, code *usertree.RecoveryCode) error {
+	guardianTree, key, err := c.userTreeAndKey(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	userTree, err := usertree.Find(ctx, code.Username, c.Tupelo)
+	if err != nil {
+		return err
+	}
+
+	guardians, err := userTree.Guardians(ctx)
+	if err != nil {
+		return err
+	}
+
+	guardian := guardians.Find(guardianTree.Name())
+	if guardian == nil || guardian.Did != guardianTree.Did() {
+		return fmt.Errorf("%s is not a guardian of %s", guardianTree.Name(), code.Username)
+	}
+
+	kr, err := keyring.NewDefault()
+	if err != nil {
+		return err
+	}
+
+	guardianKey, err := kr.FindPrivateKey(guardianTree.Name() + guardianKeySuffix)
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		return fmt.Errorf("the guardian key of %s is not on this machine, approve from the machine which ran `git dg guardian enable`", guardianTree.Name())
+	}
+	if err != nil {
+		return err
+	}
+
+	share, err := keyring.DecryptWithKey(guardianKey, guardian.Share)
+	if err != nil {
+		return fmt.Errorf("error decrypting recovery share of %s: %w", code.Username, err)
+	}
+
+	approval, err := keyring.EncryptToKey(code.PublicKey, share)
+	if err != nil {
+		return err
+	}
+
+	return guardianTree.ApproveRecovery(ctx, key, code.Username, approval)
+}
+
+// Recovery is the state of the current user's recovery on this machine
+type Recovery struct {
+	Code      *usertree.RecoveryCode
+	Approvals int
+	Threshold int
+}
+
+// NewRecovery returns the recovery of the current user in progress on this
+// machine, starting one with a new key if there is none
+func (c *Client) NewRecovery(ctx context.Context, repo *Repo) (*Recovery, error) {
+	username, err := repo.Username()
+	if err != nil {
+		return nil, err
+	}
+
+	userTree, err := usertree.Find(ctx, username, c.Tupelo)
+	if err != nil {
+		return nil, err
+	}
+
+	guardians, err := userTree.Guardians(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	kr, err := keyring.NewDefault()
+	if err != nil {
+		return nil, err
+	}
+
+	newKey, err := kr.FindPrivateKey(username + recoveringKeySuffix)
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		newKey, err = crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		err = kr.SetPrivateKey(username+recoveringKeySuffix, newKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := c.recoveryShares(ctx, userTree, guardians, newKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recovery{
+		Code:      &usertree.RecoveryCode{Username: username, PublicKey: &newKey.PublicKey},
+		Approvals: len(shares),
+		Threshold: guardians.Threshold,
+	}, nil
+}
+
+// CompleteRecovery combines the guardians' approved shares into the
+// recovery key, which makes this machine's new key the only owner of the
+// user
+func (c *Client) CompleteRecovery(ctx context.Context, repo *Repo) error {
+	username, err := repo.Username()
+	if err != nil {
+		return err
+	}
+
+	userTree, err := usertree.Find(ctx, username, c.Tupelo)
+	if err != nil {
+		return err
+	}
+
+	guardians, err := userTree.Guardians(ctx)
+	if err != nil {
+		return err
+	}
+
+	kr, err := keyring.NewDefault()
+	if err != nil {
+		return err
+	}
+
+	newKey, err := kr.FindPrivateKey(username + recoveringKeySuffix)
+	if err != nil {
+		return err
+	}
+
+	shares, err := c.recoveryShares(ctx, userTree, guardians, newKey)
+	if err != nil {
+		return err
+	}
+	if len(shares) < guardians.Threshold {
+		return ErrNotEnoughApprovals
+	}
+
+	recoveryKey, err := keyring.CombineKey(shares, guardians.Threshold)
+	if err != nil {
+		return fmt.Errorf("guardian approvals did not combine into the recovery key: %w", err)
+	}
+
+	err = userTree.Recover(ctx, recoveryKey, crypto.PubkeyToAddress(newKey.PublicKey).String())
+	if err != nil {
+		return err
+	}
+
+	if err = kr.SetPrivateKey(username, newKey); err != nil {
+		return fmt.Errorf("%s was recovered but the keyring could not be updated, its key is kept as %s%s: %w", username, username, recoveringKeySuffix, err)
+	}
+	kr.DeletePrivateKey(username + recoveringKeySuffix)
+
+	return nil
+}
+
+// recoveryShares collects the shares guardians have approved for newKey.
+// Approvals for an earlier recovery attempt fail to decrypt and are
+// skipped.
+func (c *Client) recoveryShares(ctx context.Context, userTree *usertree.UserTree, guardians *usertree.Guardians, newKey *ecdsa.PrivateKey) ([][]byte, error) {
+	shares := [][]byte{}
+	for _, guardian := range guardians.Guardians {
+		guardianTree, err := usertree.Find(ctx, guardian.Username, c.Tupelo)
+		if err != nil {
+			log.Warningf("error finding guardian %s: %v", guardian.Username, err)
+			continue
+		}
+		if guardianTree.Did() != guardian.Did {
+			continue
+		}
+
+		approval, err := guardianTree.RecoveryApproval(ctx, userTree.Name())
+		if err != nil {
+			return nil, err
+		}
+		if approval == nil {
+			continue
+		}
+
+		share, err := keyring.DecryptWithKey(newKey, approval)
+		if err != nil {
+			log.Debugf("approval of %s is not for this recovery: %v", guardian.Username, err)
+			continue
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, nil
+}
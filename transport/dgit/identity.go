@@ -0,0 +1,188 @@
+package dgit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/config"
+
+	"github.com/quorumcontrol/dgit/constants"
+	"github.com/quorumcontrol/dgit/keyring"
+)
+
+// RemoteUsernameOption selects the identity used for a single remote, as
+// remote.<name>.decentragitUsername
+const RemoteUsernameOption = "decentragitUsername"
+
+var ErrNoIdentity = errors.New("no decentragit username configured")
+
+// Identity is the username, and so keyring entry, used to act on a repo
+type Identity struct {
+	Username string
+	// Reason describes which setting selected the identity
+	Reason string
+}
+
+// ResolveIdentity picks the identity for the remote and url, in order of
+// precedence:
+//
+//  1. the DG_USERNAME env var
+//  2. remote.<remoteName>.decentragitUsername
+//  3. decentragit.<url pattern>.username, the longest pattern matching url
+//  4. decentragit.username
+//
+// remoteName and url may be empty when not known.
+func ResolveIdentity(cfg *config.Config, remoteName string, url string) (*Identity, error) {
+	envUsername := os.Getenv("DGIT_USERNAME")
+	if envUsername != "" {
+		log.Warningf("[DEPRECATION] - DGIT_USERNAME is deprecated, please use DG_USERNAME")
+	}
+	if username := os.Getenv("DG_USERNAME"); username != "" {
+		return &Identity{Username: username, Reason: "DG_USERNAME env var"}, nil
+	}
+	if envUsername != "" {
+		return &Identity{Username: envUsername, Reason: "DGIT_USERNAME env var"}, nil
+	}
+
+	if remoteName != "" {
+		remoteSection := cfg.Merged.Section("remote")
+		if remoteSection != nil && remoteSection.HasSubsection(remoteName) {
+			username := remoteSection.Subsection(remoteName).Option(RemoteUsernameOption)
+			if username != "" {
+				return &Identity{
+					Username: username,
+					Reason:   fmt.Sprintf("remote.%s.%s git config", remoteName, RemoteUsernameOption),
+				}, nil
+			}
+		}
+	}
+
+	dgitConfig := cfg.Merged.Section(constants.DgitConfigSection)
+	if dgitConfig == nil {
+		return nil, ErrNoIdentity
+	}
+
+	if url != "" {
+		patterns := make([]string, 0)
+		for _, subsection := range dgitConfig.Subsections() {
+			if subsection.Option("username") != "" && matchesURLPattern(subsection.Name(), url) {
+				patterns = append(patterns, subsection.Name())
+			}
+		}
+
+		if len(patterns) > 0 {
+			// most specific pattern wins
+			sort.Slice(patterns, func(i, j int) bool {
+				return len(patterns[i]) > len(patterns[j])
+			})
+			return &Identity{
+				Username: dgitConfig.Subsection(patterns[0]).Option("username"),
+				Reason:   fmt.Sprintf("%s.%s.username git config matching %s", constants.DgitConfigSection, patterns[0], url),
+			}, nil
+		}
+	}
+
+	if username := dgitConfig.Option("username"); username != "" {
+		return &Identity{
+			Username: username,
+			Reason:   fmt.Sprintf("%s.username git config", constants.DgitConfigSection),
+		}, nil
+	}
+
+	return nil, ErrNoIdentity
+}
+
+// matchesURLPattern reports whether url matches the glob pattern, or is
+// under it when pattern is a prefix such as dg://my-org
+func matchesURLPattern(pattern string, url string) bool {
+	if !strings.Contains(pattern, "://") {
+		return false
+	}
+
+	if matched, _ := path.Match(pattern, url); matched {
+		return true
+	}
+
+	return strings.HasPrefix(url, strings.TrimSuffix(pattern, "/")+"/")
+}
+
+// Identities returns the usernames with a key in the keyring
+func Identities(kr *keyring.Keyring) ([]string, error) {
+	names, err := kr.Names()
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]string, 0, len(names))
+	for _, name := range names {
+		if isInternalKeyName(name) {
+			continue
+		}
+		identities = append(identities, name)
+	}
+	sort.Strings(identities)
+
+	return identities, nil
+}
+
+// UseIdentity makes username the identity of the repo, or of only the
+// given remote or url pattern, in the repo's local git config
+func (r *Repo) UseIdentity(username string, remoteName string, urlPattern string) error {
+	if remoteName != "" && urlPattern != "" {
+		return fmt.Errorf("an identity can be used for a remote or a url pattern, not both")
+	}
+	if urlPattern != "" && !strings.Contains(urlPattern, "://") {
+		return fmt.Errorf("url pattern %q must include the protocol, e.g. %s://my-org/*", urlPattern, constants.Protocol)
+	}
+
+	kr, err := keyring.NewDefault()
+	if err != nil {
+		return err
+	}
+
+	_, err = kr.FindPrivateKey(username)
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		return fmt.Errorf("no key for %s in %s, add one with `git dg init` or `git dg key import`", username, kr.Name())
+	}
+	if err != nil {
+		return err
+	}
+
+	repoConfig, err := r.Config()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case remoteName != "":
+		if _, ok := repoConfig.Remotes[remoteName]; !ok {
+			return fmt.Errorf("remote %s does not exist", remoteName)
+		}
+		repoConfig.Raw.Section("remote").Subsection(remoteName).SetOption(RemoteUsernameOption, username)
+	case urlPattern != "":
+		repoConfig.Raw.Section(constants.DgitConfigSection).Subsection(urlPattern).SetOption("username", username)
+	default:
+		repoConfig.Raw.Section(constants.DgitConfigSection).SetOption("username", username)
+	}
+
+	if err = repoConfig.Validate(); err != nil {
+		return err
+	}
+
+	return r.Storer.SetConfig(repoConfig)
+}
+
+// isInternalKeyName reports whether the keyring entry is a helper key of a
+// user rather than a user's own key
+func isInternalKeyName(name string) bool {
+	for _, suffix := range []string{pendingKeySuffix, guardianKeySuffix, recoveringKeySuffix} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package dgit
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-git/go-git/v5/config"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveIdentity(t *testing.T) {
+	os.Unsetenv("DG_USERNAME")
+	os.Unsetenv("DGIT_USERNAME")
+
+	cfg := config.NewConfig()
+	err := cfg.UnmarshalScoped(format.GlobalScope, []byte(`
+[decentragit]
+	username = alice
+[decentragit "dg://acme/*"]
+	username = alice-acme
+[decentragit "dg://acme/secret"]
+	username = alice-secret
+`))
+	require.Nil(t, err)
+	err = cfg.UnmarshalScoped(format.LocalScope, []byte(`
+[remote "client"]
+	url = dg://client/repo
+	decentragitUsername = alice-client
+`))
+	require.Nil(t, err)
+
+	identity, err := ResolveIdentity(cfg, "", "dg://alice/repo")
+	require.Nil(t, err)
+	require.Equal(t, "alice", identity.Username)
+	require.Equal(t, "decentragit.username git config", identity.Reason)
+
+	identity, err = ResolveIdentity(cfg, "", "dg://acme/repo")
+	require.Nil(t, err)
+	require.Equal(t, "alice-acme", identity.Username)
+
+	identity, err = ResolveIdentity(cfg, "", "dg://acme/secret")
+	require.Nil(t, err)
+	require.Equal(t, "alice-secret", identity.Username)
+
+	identity, err = ResolveIdentity(cfg, "client", "dg://client/repo")
+	require.Nil(t, err)
+	require.Equal(t, "alice-client", identity.Username)
+	require.Equal(t, "remote.client.decentragitUsername git config", identity.Reason)
+
+	_, err = ResolveIdentity(config.NewConfig(), "", "")
+	require.Equal(t, ErrNoIdentity, err)
+
+	os.Setenv("DG_USERNAME", "bob")
+	defer os.Unsetenv("DG_USERNAME")
+	identity, err = ResolveIdentity(cfg, "client", "dg://client/repo")
+	require.Nil(t, err)
+	require.Equal(t, "bob", identity.Username)
+}
+
+func TestMatchesURLPattern(t *testing.T) {
+	require.True(t, matchesURLPattern("dg://acme", "dg://acme/repo"))
+	require.True(t, matchesURLPattern("dg://acme/", "dg://acme/repo"))
+	require.True(t, matchesURLPattern("dg://acme/web-*", "dg://acme/web-app"))
+	require.False(t, matchesURLPattern("dg://acme/web-*", "dg://acme/api"))
+	require.False(t, matchesURLPattern("dg://acme", "dg://acme-corp/repo"))
+	require.False(t, matchesURLPattern("hooks", "dg://hooks/repo"))
+}
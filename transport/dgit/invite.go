@@ -0,0 +1,136 @@
+package dgit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quorumcontrol/dgit/tupelo/teamtree"
+)
+
+// TeamInvitation is a pending invitation to one of a repo's teams
+type TeamInvitation struct {
+	*teamtree.Invitation
+	Team string
+	// Accepted is true once the invitee accepted, they become a member
+	// once an admin runs ConfirmRepoInvitations
+	Accepted bool
+}
+
+// ListRepoInvitations returns the pending invitations of all repo teams
+func (c *Client) ListRepoInvitations(ctx context.Context, repo *Repo) ([]*TeamInvitation, error) {
+	repoName, err := repo.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	repoTree, err := c.FindRepoTree(ctx, repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := repoTree.Teams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := []*TeamInvitation{}
+	for _, team := range teams {
+		teamInvitations, err := team.Tree.Invitations(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, invitation := range teamInvitations {
+			accepted, err := repoTree.InviteAccepted(ctx, team.Tree.Did(), invitation)
+			if err != nil {
+				return nil, err
+			}
+
+			invitations = append(invitations, &TeamInvitation{
+				Invitation: invitation,
+				Team:       team.Name,
+				Accepted:   accepted,
+			})
+		}
+	}
+
+	return invitations, nil
+}
+
+// ConfirmRepoInvitations adds the invitees which accepted their invitations
+// to the repo's teams, and returns their invitations
+func (c *Client) ConfirmRepoInvitations(ctx context.Context, repo *Repo) ([]*TeamInvitation, error) {
+	repoTree, key, err := c.repoTreeAndKey(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := repoTree.Teams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed := []*TeamInvitation{}
+	for _, team := range teams {
+		accepted, err := repoTree.AddAcceptedInvitees(ctx, key, team.Name)
+		if err != nil {
+			return nil, fmt.Errorf("team %s: %w", team.Name, err)
+		}
+
+		for _, invitation := range accepted {
+			confirmed = append(confirmed, &TeamInvitation{
+				Invitation: invitation,
+				Team:       team.Name,
+				Accepted:   true,
+			})
+		}
+	}
+
+	return confirmed, nil
+}
+
+// InvitedTeam returns the team an invite code is for
+func (c *Client) InvitedTeam(ctx context.Context, code *teamtree.InviteCode) (*teamtree.TeamTree, error) {
+	return teamtree.Find(ctx, c.Tupelo, code.Team)
+}
+
+// AcceptInvite accepts the invitation of the code in the current user's
+// tree, signed by their key, which must be on this machine. The code must be
+// for the current user and their current invitation to the team.
+func (c *Client) AcceptInvite(ctx context.Context, repo *Repo, code *teamtree.InviteCode) error {
+	userTree, key, err := c.userTreeAndKey(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	username, err := repo.Username()
+	if err != nil {
+		return err
+	}
+	if code.Username != username {
+		return fmt.Errorf("this invitation is for %s, but you are %s", code.Username, username)
+	}
+
+	team, err := c.InvitedTeam(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	invitation, err := team.Invitation(ctx, username)
+	if err != nil {
+		return err
+	}
+	if invitation.Did != userTree.Did() {
+		return fmt.Errorf("the invitation of %s is for user %s, not %s", username, invitation.Did, userTree.Did())
+	}
+	if invitation.ID != code.ID {
+		return fmt.Errorf("%w: the code is not for the current invitation of %s", teamtree.ErrInvalidInviteCode, username)
+	}
+
+	signature, err := code.Sign(key)
+	if err != nil {
+		return err
+	}
+
+	return userTree.AcceptInvite(ctx, key, team.Did(), signature)
+}
@@ -0,0 +1,226 @@
+package dgit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/quorumcontrol/dgit/keyring"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+// pendingKeySuffix names the keyring entry holding a new key until its
+// rotation has been notarized
+const pendingKeySuffix = ".rotating"
+
+var ErrPreviousRotationCompleted = errors.New("a previously interrupted key rotation had completed, its recovery phrase is now active")
+
+// ErrRecoveryKeyNotRotated is returned by KeyRotation.Commit when the key
+// was rotated but the guardians' recovery key could not be replaced
+var ErrRecoveryKeyNotRotated = errors.New("the key was rotated but the recovery key held by your guardians was not, run `git dg guardian set` with the same guardians to replace it")
+
+// KeyRotation replaces the current user's key with a new mnemonic derived
+// key. The new key is kept in the keyring under a pending name until the
+// user tree ownership change is notarized, so the old key stays usable if
+// anything fails before then.
+type KeyRotation struct {
+	Username string
+	Mnemonic string
+
+	client   *Client
+	keyring  *keyring.Keyring
+	userTree *usertree.UserTree
+	oldKey   *ecdsa.PrivateKey
+	newKey   *ecdsa.PrivateKey
+}
+
+// NewKeyRotation generates the new key and stores it as pending. Show
+// Mnemonic to the user before calling Commit.
+func (c *Client) NewKeyRotation(ctx context.Context, repo *Repo) (*KeyRotation, error) {
+	username, err := repo.Username()
+	if err != nil {
+		return nil, err
+	}
+
+	kr, err := keyring.NewDefault()
+	if err != nil {
+		return nil, err
+	}
+
+	// resume before checking the current key, which an interrupted
+	// rotation may already have replaced
+	if err = c.resumeKeyRotation(ctx, kr, username); err != nil {
+		return nil, err
+	}
+
+	userTree, oldKey, err := c.userTreeAndKey(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	entropy, err := bip39.NewEntropy(256)
+	if err != nil {
+		return nil, fmt.Errorf("error generating entropy for mnemoic seed: %w", err)
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, fmt.Errorf("error generating mnemoic seed: %w", err)
+	}
+
+	newKey, err := kr.CreatePrivateKey(username+pendingKeySuffix, bip39.NewSeed(mnemonic, username))
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyRotation{
+		Username: username,
+		Mnemonic: mnemonic,
+		client:   c,
+		keyring:  kr,
+		userTree: userTree,
+		oldKey:   oldKey,
+		newKey:   newKey,
+	}, nil
+}
+
+// resumeKeyRotation cleans up after a rotation which was interrupted
+// between notarizing and updating the keyring. If the pending key already
+// owns the user tree it becomes the user's key.
+func (c *Client) resumeKeyRotation(ctx context.Context, kr *keyring.Keyring, username string) error {
+	pendingName := username + pendingKeySuffix
+
+	pendingKey, err := kr.FindPrivateKey(pendingName)
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	userTree, err := usertree.Find(ctx, username, c.Tupelo)
+	if err != nil {
+		return err
+	}
+
+	isOwner, err := userTree.IsOwner(ctx, crypto.PubkeyToAddress(pendingKey.PublicKey).String())
+	if err != nil {
+		return err
+	}
+
+	if isOwner {
+		if err = kr.SetPrivateKey(username, pendingKey); err != nil {
+			return err
+		}
+		kr.DeletePrivateKey(pendingName)
+		return ErrPreviousRotationCompleted
+	}
+
+	kr.DeletePrivateKey(pendingName)
+	return nil
+}
+
+// Commit replaces the old key's address in the user tree owners and then
+// makes the new key the user's key in the keyring. A user with guardians
+// gets a new recovery key split between them as well, since a leaked key
+// could have read the old one's shares.
+func (r *KeyRotation) Commit(ctx context.Context) error {
+	newAddr := crypto.PubkeyToAddress(r.newKey.PublicKey).String()
+
+	err := r.userTree.RotateOwner(ctx, r.oldKey, newAddr)
+	if err != nil {
+		// the transaction may have been notarized even though playing it
+		// failed, check before deciding which key is valid
+		latest, findErr := usertree.Find(ctx, r.Username, r.client.Tupelo)
+		if findErr != nil {
+			return fmt.Errorf("key rotation failed and its state could not be verified, the new key is kept as %s%s until the next rotate: %w", r.Username, pendingKeySuffix, err)
+		}
+
+		isOwner, ownerErr := latest.IsOwner(ctx, newAddr)
+		if ownerErr != nil || !isOwner {
+			r.Abort()
+			return fmt.Errorf("key rotation failed, your existing key and recovery phrase are unchanged: %w", err)
+		}
+	}
+
+	if err = r.keyring.SetPrivateKey(r.Username, r.newKey); err != nil {
+		return fmt.Errorf("key rotation was notarized but the keyring could not be updated, run key rotate again to finish: %w", err)
+	}
+	r.keyring.DeletePrivateKey(r.Username + pendingKeySuffix)
+
+	latest, err := usertree.Find(ctx, r.Username, r.client.Tupelo)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRecoveryKeyNotRotated, err)
+	}
+	if err = r.client.rotateRecoveryKey(ctx, latest, r.newKey); err != nil {
+		return fmt.Errorf("%w: %v", ErrRecoveryKeyNotRotated, err)
+	}
+
+	return nil
+}
+
+// Abort discards the pending key, leaving the current key in place
+func (r *KeyRotation) Abort() {
+	r.keyring.DeletePrivateKey(r.Username + pendingKeySuffix)
+}
+
+var (
+	ErrKeyFileNotOwner = errors.New("key file's key is not an owner of its user")
+	ErrKeyExists       = errors.New("a different key for this user is already in the keyring")
+)
+
+// ExportKey encrypts the current user's key into a key file, which can be
+// imported on another machine
+func (c *Client) ExportKey(ctx context.Context, repo *Repo, passphrase string) (*keyring.KeyFile, error) {
+	userTree, key, err := c.userTreeAndKey(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return keyring.NewKeyFile(userTree.Name(), userTree.Did(), key, passphrase)
+}
+
+// ImportKey decrypts the key file and saves its key to the keyring, after
+// checking the key is still an owner of the file's user. An existing
+// different key is only replaced if overwrite is set.
+func (c *Client) ImportKey(ctx context.Context, keyFile *keyring.KeyFile, passphrase string, overwrite bool) error {
+	key, err := keyFile.PrivateKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	userTree, err := usertree.Find(ctx, keyFile.Username, c.Tupelo)
+	if err != nil {
+		return err
+	}
+
+	if userTree.Did() != keyFile.Did {
+		return fmt.Errorf("key file is for %s, but user %s is %s", keyFile.Did, keyFile.Username, userTree.Did())
+	}
+
+	isOwner, err := userTree.IsOwner(ctx, crypto.PubkeyToAddress(key.PublicKey).String())
+	if err != nil {
+		return err
+	}
+	if !isOwner {
+		return ErrKeyFileNotOwner
+	}
+
+	kr, err := keyring.NewDefault()
+	if err != nil {
+		return err
+	}
+
+	existing, err := kr.FindPrivateKey(keyFile.Username)
+	if err != nil && !errors.Is(err, keyring.ErrKeyNotFound) {
+		return err
+	}
+	if existing != nil && !overwrite && crypto.PubkeyToAddress(existing.PublicKey) != crypto.PubkeyToAddress(key.PublicKey) {
+		return ErrKeyExists
+	}
+
+	return kr.SetPrivateKey(keyFile.Username, key)
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/server"
@@ -41,11 +42,15 @@ func NewChainTreeLoader(ctx context.Context, tupelo *tupelo.Client, nodestore no
 func (l *ChainTreeLoader) Load(ep *transport.Endpoint) (storer.Storer, error) {
 	repoTree, err := repotree.Find(l.ctx, ep.Host+ep.Path, l.tupelo)
 
-	var privateKey *ecdsa.PrivateKey
+	var (
+		privateKey  *ecdsa.PrivateKey
+		pushOptions []string
+	)
 
 	switch auth := l.auth.(type) {
 	case *PrivateKeyAuth:
 		privateKey = auth.Key()
+		pushOptions = auth.PushOptions
 	case nil:
 		// noop
 	default:
@@ -60,10 +65,30 @@ func (l *ChainTreeLoader) Load(ep *transport.Endpoint) (storer.Storer, error) {
 		return nil, err
 	}
 
-	return chaintree.NewStorage(&storage.Config{
-		Ctx:        l.ctx,
-		Tupelo:     l.tupelo,
-		ChainTree:  repoTree.ChainTree(),
-		PrivateKey: privateKey,
-	})
+	config := &storage.Config{
+		Ctx:         l.ctx,
+		Tupelo:      l.tupelo,
+		ChainTree:   repoTree.ChainTree(),
+		PrivateKey:  privateKey,
+		PushOptions: pushOptions,
+	}
+
+	var guard *repoGuard
+	if privateKey != nil {
+		guard = newRepoGuard(l.ctx, repoTree, crypto.PubkeyToAddress(privateKey.PublicKey).String())
+		config.ReferenceGuard = guard
+		config.Pusher = guard
+	}
+
+	st, err := chaintree.NewStorage(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// protection rules need the repo objects to detect force pushes
+	if guard != nil {
+		guard.objects = st
+	}
+
+	return st, nil
 }
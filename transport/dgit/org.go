@@ -0,0 +1,98 @@
+package dgit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quorumcontrol/dgit/tupelo/orgtree"
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
+	"github.com/quorumcontrol/dgit/tupelo/teamtree"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+func (c *Client) CreateOrg(ctx context.Context, repo *Repo, name string) (*orgtree.OrgTree, error) {
+	key, err := authKey(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := repo.Username()
+	if err != nil {
+		return nil, err
+	}
+
+	err = repotree.CheckNameAvailable(ctx, name, c.Tupelo)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := usertree.Find(ctx, username, c.Tupelo)
+	if err != nil {
+		return nil, err
+	}
+
+	isOwner, err := user.IsOwner(ctx, NewPrivateKeyAuth(key).String())
+	if err != nil {
+		return nil, err
+	}
+	if !isOwner {
+		return nil, fmt.Errorf("current key is not an owner of user %s", username)
+	}
+
+	return orgtree.Create(ctx, &orgtree.Options{
+		Name:    name,
+		Tupelo:  c.Tupelo,
+		Members: teamtree.Members{user},
+	})
+}
+
+func (c *Client) AddOrgMembers(ctx context.Context, repo *Repo, name string, usernames []string) error {
+	key, err := authKey(repo)
+	if err != nil {
+		return err
+	}
+
+	orgTree, err := orgtree.Find(ctx, name, c.Tupelo)
+	if err != nil {
+		return err
+	}
+
+	team, err := orgTree.Team(ctx)
+	if err != nil {
+		return err
+	}
+
+	members := make(teamtree.Members, len(usernames))
+	for i, username := range usernames {
+		user, err := usertree.Find(ctx, username, c.Tupelo)
+		if err == usertree.ErrNotFound {
+			return fmt.Errorf("User %s not found", username)
+		}
+		if err != nil {
+			return err
+		}
+
+		members[i] = teamtree.NewMember(user.Did(), username)
+	}
+
+	return team.AddMembers(ctx, key, members)
+}
+
+func (c *Client) ListOrgMembers(ctx context.Context, name string) ([]string, error) {
+	orgTree, err := orgtree.Find(ctx, name, c.Tupelo)
+	if err != nil {
+		return []string{}, err
+	}
+
+	team, err := orgTree.Team(ctx)
+	if err != nil {
+		return []string{}, err
+	}
+
+	members, err := team.ListMembers(ctx)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return members.Names(), nil
+}
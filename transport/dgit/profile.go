@@ -0,0 +1,26 @@
+package dgit
+
+import (
+	"context"
+
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+func (c *Client) UserProfile(ctx context.Context, username string) (*usertree.Profile, error) {
+	userTree, err := usertree.Find(ctx, username, c.Tupelo)
+	if err != nil {
+		return nil, err
+	}
+
+	return userTree.Profile(ctx)
+}
+
+// SetUserProfile replaces the current user's profile
+func (c *Client) SetUserProfile(ctx context.Context, repo *Repo, profile *usertree.Profile) error {
+	userTree, key, err := c.userTreeAndKey(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	return userTree.SetProfile(ctx, key, profile)
+}
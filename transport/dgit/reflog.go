@@ -0,0 +1,52 @@
+package dgit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
+)
+
+// RefHistory returns the values the ref held in the repo, newest first
+func (c *Client) RefHistory(ctx context.Context, repo *Repo, name plumbing.ReferenceName, limit int) ([]*repotree.RefChange, error) {
+	repoName, err := repo.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	repoTree, err := c.FindRepoTree(ctx, repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	return repoTree.RefHistory(ctx, name, limit)
+}
+
+// RestoreRef points the ref back at hash, typically a value from its
+// history lost to a force push. The update is checked and recorded like a
+// push, so it is subject to the repo's roles and protection rules.
+func (c *Client) RestoreRef(ctx context.Context, repo *Repo, name plumbing.ReferenceName, hash plumbing.Hash) error {
+	endpoint, err := repo.Endpoint()
+	if err != nil {
+		return err
+	}
+
+	auth, err := repo.Auth()
+	if err != nil {
+		return err
+	}
+
+	st, err := NewChainTreeLoader(ctx, c.Tupelo, c.Nodestore, auth).Load(endpoint)
+	if err != nil {
+		return err
+	}
+
+	_, err = st.EncodedObject(plumbing.AnyObject, hash)
+	if err != nil {
+		return fmt.Errorf("can not restore %s to %s: %w", name, hash, err)
+	}
+
+	return st.SetReference(plumbing.NewHashReference(name, hash))
+}
@@ -3,7 +3,6 @@ package dgit
 import (
 	"errors"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 
@@ -19,8 +18,9 @@ var ErrEndpointNotFound = errors.New("endpoint not found")
 type Repo struct {
 	*git.Repository
 
-	endpoint *transport.Endpoint
-	auth     transport.AuthMethod
+	endpoint   *transport.Endpoint
+	remoteName string
+	auth       transport.AuthMethod
 }
 
 func NewRepo(gitRepo *git.Repository) *Repo {
@@ -47,25 +47,20 @@ func (r *Repo) Endpoint() (*transport.Endpoint, error) {
 		return iName == "origin" || iName == constants.DgitRemote
 	})
 
-	dgitUrls := []string{}
-
 	for _, remote := range remotes {
 		for _, url := range remote.Config().URLs {
 			if strings.HasPrefix(url, constants.Protocol) {
-				dgitUrls = append(dgitUrls, url)
-			}
-		}
-	}
+				ep, err := transport.NewEndpoint(url)
+				if err != nil {
+					return nil, err
+				}
 
-	if len(dgitUrls) > 0 {
-		ep, err := transport.NewEndpoint(dgitUrls[0])
-		if err != nil {
-			return nil, err
-		}
-
-		r.endpoint = ep
+				r.endpoint = ep
+				r.remoteName = remote.Config().Name
 
-		return ep, nil
+				return ep, nil
+			}
+		}
 	}
 
 	return nil, ErrEndpointNotFound
@@ -82,6 +77,17 @@ func (r *Repo) MustEndpoint() *transport.Endpoint {
 
 func (r *Repo) SetEndpoint(endpoint *transport.Endpoint) {
 	r.endpoint = endpoint
+	r.remoteName = ""
+}
+
+// RemoteName returns the name of the git remote with the repo's dg url, or
+// "" if the endpoint was set directly
+func (r *Repo) RemoteName() (string, error) {
+	if _, err := r.Endpoint(); err != nil {
+		return "", err
+	}
+
+	return r.remoteName, nil
 }
 
 func (r *Repo) Name() (string, error) {
@@ -121,34 +127,39 @@ func (r *Repo) MustURL() string {
 }
 
 func (r *Repo) Username() (string, error) {
-	repoConfig, err := r.Config()
+	identity, err := r.Identity()
 	if err != nil {
 		return "", err
 	}
 
-	dgitConfig := repoConfig.Merged.Section(constants.DgitConfigSection)
+	return identity.Username, nil
+}
 
-	if dgitConfig == nil {
-		return "", fmt.Errorf("no decentragit configuration found; run `git config --global %s.username your-username`", constants.DgitConfigSection)
+// Identity resolves the identity for the repo's dg remote, see
+// ResolveIdentity
+func (r *Repo) Identity() (*Identity, error) {
+	repoConfig, err := r.Config()
+	if err != nil {
+		return nil, err
 	}
 
-	username := dgitConfig.Option("username")
-
-	envUsername := os.Getenv("DGIT_USERNAME")
-	if envUsername != "" {
-		log.Warningf("[DEPRECATION] - DGIT_USERNAME is deprecated, please use DG_USERNAME")
-		username = envUsername
-	}
-	envUsername = os.Getenv("DG_USERNAME")
-	if envUsername != "" {
-		username = envUsername
+	var url string
+	ep, err := r.Endpoint()
+	if err == nil {
+		url = ep.String()
+	} else if err != ErrEndpointNotFound {
+		return nil, err
 	}
 
-	if username == "" {
-		return "", fmt.Errorf("no decentragit username found; run `git config --global %s.username your-username`", constants.DgitConfigSection)
+	identity, err := ResolveIdentity(repoConfig, r.remoteName, url)
+	if err == ErrNoIdentity {
+		return nil, fmt.Errorf("no decentragit username found; run `git config --global %s.username your-username`", constants.DgitConfigSection)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return username, nil
+	return identity, nil
 }
 
 func (r *Repo) Auth() (transport.AuthMethod, error) {
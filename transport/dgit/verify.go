@@ -0,0 +1,246 @@
+package dgit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+var (
+	ErrUnsigned      = errors.New("not signed")
+	ErrUnknownAuthor = errors.New("author email is not the profile email of any repo collaborator")
+	ErrUnknownSigner = errors.New("not signed by a key of its author")
+)
+
+// Verification is the result of checking the signature of a commit or tag
+type Verification struct {
+	Hash plumbing.Hash
+	// Username is the dg user whose profile key made the signature
+	Username string
+	// KeyID is the GPG fingerprint or SSH key fingerprint of the signing key
+	KeyID string
+	Err   error
+}
+
+func (v *Verification) String() string {
+	if v.Err != nil {
+		return fmt.Sprintf("%s %v", v.Hash, v.Err)
+	}
+	return fmt.Sprintf("%s signed by %s (%s)", v.Hash, v.Username, v.KeyID)
+}
+
+// Verifier checks signatures against the GPG and SSH keys published in the
+// profile of the repo collaborator whose profile email is the author's
+type Verifier struct {
+	usernames []string
+	profiles  map[string]*usertree.Profile
+}
+
+// NewVerifier loads the profiles of every member of the repo's teams
+func (c *Client) NewVerifier(ctx context.Context, repoName string) (*Verifier, error) {
+	repoTree, err := c.FindRepoTree(ctx, repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := repoTree.Teams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Verifier{
+		usernames: []string{},
+		profiles:  make(map[string]*usertree.Profile),
+	}
+
+	for _, team := range teams {
+		members, err := team.Tree.ExpandMembers(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, username := range members.Names() {
+			if _, ok := v.profiles[username]; ok {
+				continue
+			}
+
+			profile, err := c.UserProfile(ctx, username)
+			if err != nil {
+				return nil, fmt.Errorf("error loading profile of %s: %w", username, err)
+			}
+			v.profiles[username] = profile
+			v.usernames = append(v.usernames, username)
+		}
+	}
+	sort.Strings(v.usernames)
+
+	return v, nil
+}
+
+func (v *Verifier) VerifyCommit(commit *object.Commit) *Verification {
+	encoded := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(encoded); err != nil {
+		return &Verification{Hash: commit.Hash, Err: err}
+	}
+
+	return v.verify(commit.Hash, commit.Author.Email, encoded, commit.PGPSignature)
+}
+
+func (v *Verifier) VerifyTag(tag *object.Tag) *Verification {
+	signature := tag.PGPSignature
+	unsigned := tag
+
+	// go-git only splits PGP signatures off of tag messages
+	if signature == "" {
+		if i := strings.Index(tag.Message, usertree.SSHSignaturePrefix); i >= 0 {
+			copied := *tag
+			copied.Message = tag.Message[:i]
+			signature = tag.Message[i:]
+			unsigned = &copied
+		}
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err := unsigned.EncodeWithoutSignature(encoded); err != nil {
+		return &Verification{Hash: tag.Hash, Err: err}
+	}
+
+	return v.verify(tag.Hash, tag.Tagger.Email, encoded, signature)
+}
+
+// authors returns the collaborators whose profile email is email
+func (v *Verifier) authors(email string) []string {
+	authors := []string{}
+	for _, username := range v.usernames {
+		profileEmail := v.profiles[username].Email
+		if profileEmail != "" && strings.EqualFold(profileEmail, email) {
+			authors = append(authors, username)
+		}
+	}
+	return authors
+}
+
+func (v *Verifier) verify(hash plumbing.Hash, email string, encoded *plumbing.MemoryObject, signature string) *Verification {
+	result := &Verification{Hash: hash}
+
+	if strings.TrimSpace(signature) == "" {
+		result.Err = ErrUnsigned
+		return result
+	}
+
+	reader, err := encoded.Reader()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	payload, err := ioutil.ReadAll(reader)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// only the author's own keys count, so that one collaborator can't
+	// vouch for commits made in another's name
+	authors := v.authors(email)
+	if len(authors) == 0 {
+		result.Err = fmt.Errorf("%w: %s", ErrUnknownAuthor, email)
+		return result
+	}
+
+	for _, username := range authors {
+		keyID, err := v.profiles[username].VerifySignature(payload, signature)
+		if err == usertree.ErrNoMatchingKey {
+			continue
+		}
+		if err != nil {
+			result.Err = fmt.Errorf("bad signature: %w", err)
+			return result
+		}
+
+		result.Username = username
+		result.KeyID = keyID
+		return result
+	}
+
+	result.Err = ErrUnknownSigner
+	return result
+}
+
+// VerifyNewCommits verifies the commits reachable from tip which aren't
+// reachable from any of the known hashes, such as the remote's refs. Known
+// hashes missing from s are skipped.
+func (v *Verifier) VerifyNewCommits(s storer.EncodedObjectStorer, tip *object.Commit, known []plumbing.Hash) ([]*Verification, error) {
+	seen, err := reachable(s, known)
+	if err != nil {
+		return nil, err
+	}
+
+	verifications := []*Verification{}
+
+	iter := object.NewCommitPreorderIter(tip, seen, nil)
+	err = iter.ForEach(func(commit *object.Commit) error {
+		verifications = append(verifications, v.VerifyCommit(commit))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return verifications, nil
+}
+
+// reachable returns every commit reachable from hashes, which may be
+// commits or tags. Walking only stops at the hashes themselves otherwise,
+// and a merge of a known branch would verify its whole history again.
+func reachable(s storer.EncodedObjectStorer, hashes []plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	seen := make(map[plumbing.Hash]bool)
+
+	for _, hash := range hashes {
+		obj, err := object.GetObject(s, hash)
+		if err == plumbing.ErrObjectNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var commit *object.Commit
+		switch o := obj.(type) {
+		case *object.Commit:
+			commit = o
+		case *object.Tag:
+			commit, err = o.Commit()
+			if err == object.ErrUnsupportedObject || err == plumbing.ErrObjectNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+
+		if seen[commit.Hash] {
+			continue
+		}
+
+		err = object.NewCommitPreorderIter(commit, seen, nil).ForEach(func(c *object.Commit) error {
+			seen[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return seen, nil
+}
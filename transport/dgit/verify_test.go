@@ -0,0 +1,146 @@
+package dgit
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+func armoredPublicKey(t *testing.T, entity *openpgp.Entity) string {
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	require.Nil(t, err)
+	require.Nil(t, entity.Serialize(w))
+	require.Nil(t, w.Close())
+	return buf.String()
+}
+
+func signCommit(t *testing.T, commit *object.Commit, entity *openpgp.Entity) {
+	encoded := &plumbing.MemoryObject{}
+	require.Nil(t, commit.EncodeWithoutSignature(encoded))
+	reader, err := encoded.Reader()
+	require.Nil(t, err)
+	payload, err := ioutil.ReadAll(reader)
+	require.Nil(t, err)
+
+	var signature bytes.Buffer
+	require.Nil(t, openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(payload), nil))
+	commit.PGPSignature = signature.String()
+}
+
+func TestVerifyCommit(t *testing.T) {
+	alice, err := openpgp.NewEntity("Alice", "", "alice@example.com", nil)
+	require.Nil(t, err)
+	mallory, err := openpgp.NewEntity("Mallory", "", "mallory@example.com", nil)
+	require.Nil(t, err)
+
+	verifier := &Verifier{
+		usernames: []string{"alice", "bob"},
+		profiles: map[string]*usertree.Profile{
+			"alice": {Email: "alice@example.com", GPGKeys: []string{armoredPublicKey(t, alice)}},
+			"bob":   {Email: "bob@example.com"},
+		},
+	}
+
+	signature := object.Signature{Name: "Alice", Email: "alice@example.com", When: time.Unix(1600000000, 0)}
+	commit := &object.Commit{
+		Author:    signature,
+		Committer: signature,
+		Message:   "signed commit\n",
+		TreeHash:  plumbing.NewHash("4b825dc642cb6eb9a060e54bf8d69288fbee4904"),
+	}
+
+	require.Equal(t, ErrUnsigned, verifier.VerifyCommit(commit).Err)
+
+	signCommit(t, commit, alice)
+	verification := verifier.VerifyCommit(commit)
+	require.Nil(t, verification.Err)
+	require.Equal(t, "alice", verification.Username)
+
+	commit.Message = "tampered\n"
+	require.NotNil(t, verifier.VerifyCommit(commit).Err)
+
+	signCommit(t, commit, mallory)
+	require.Equal(t, ErrUnknownSigner, verifier.VerifyCommit(commit).Err)
+
+	// alice's key doesn't vouch for commits made as bob, or as anyone
+	// else
+	commit.Author = object.Signature{Name: "Bob", Email: "bob@example.com", When: time.Unix(1600000000, 0)}
+	signCommit(t, commit, alice)
+	require.Equal(t, ErrUnknownSigner, verifier.VerifyCommit(commit).Err)
+
+	commit.Author = object.Signature{Name: "Carol", Email: "carol@example.com", When: time.Unix(1600000000, 0)}
+	signCommit(t, commit, alice)
+	require.True(t, errors.Is(verifier.VerifyCommit(commit).Err, ErrUnknownAuthor))
+}
+
+func TestVerifyNewCommitsAfterMerge(t *testing.T) {
+	alice, err := openpgp.NewEntity("Alice", "", "alice@example.com", nil)
+	require.Nil(t, err)
+
+	verifier := &Verifier{
+		usernames: []string{"alice"},
+		profiles: map[string]*usertree.Profile{
+			"alice": {Email: "alice@example.com", GPGKeys: []string{armoredPublicKey(t, alice)}},
+		},
+	}
+
+	s := memory.NewStorage()
+	when := time.Unix(1600000000, 0)
+	commit := func(message string, signed bool, parents ...plumbing.Hash) *object.Commit {
+		when = when.Add(time.Minute)
+		signature := object.Signature{Name: "Alice", Email: "alice@example.com", When: when}
+		c := &object.Commit{
+			Author:       signature,
+			Committer:    signature,
+			Message:      message,
+			TreeHash:     plumbing.NewHash("4b825dc642cb6eb9a060e54bf8d69288fbee4904"),
+			ParentHashes: parents,
+		}
+		if signed {
+			signCommit(t, c, alice)
+		}
+
+		obj := s.NewEncodedObject()
+		require.Nil(t, c.Encode(obj))
+		hash, err := s.SetEncodedObject(obj)
+		require.Nil(t, err)
+
+		stored, err := object.GetCommit(s, hash)
+		require.Nil(t, err)
+		return stored
+	}
+
+	// the remote's master has unsigned history from before signatures were
+	// required
+	root := commit("root\n", false)
+	master := commit("master\n", false, root.Hash)
+	feature := commit("feature\n", true, root.Hash)
+	merge := commit("merge\n", true, master.Hash, feature.Hash)
+
+	verifications, err := verifier.VerifyNewCommits(s, merge, []plumbing.Hash{master.Hash, plumbing.NewHash("ffffffffffffffffffffffffffffffffffffffff")})
+	require.Nil(t, err)
+
+	verified := []plumbing.Hash{}
+	for _, verification := range verifications {
+		require.Nil(t, verification.Err)
+		verified = append(verified, verification.Hash)
+	}
+	require.ElementsMatch(t, []plumbing.Hash{merge.Hash, feature.Hash}, verified)
+
+	// without known refs the whole history is new
+	verifications, err = verifier.VerifyNewCommits(s, merge, nil)
+	require.Nil(t, err)
+	require.Len(t, verifications, 4)
+}
@@ -0,0 +1,386 @@
+package dgit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/quorumcontrol/messages/v2/build/go/gossip"
+
+	"github.com/quorumcontrol/dgit/hooks"
+	"github.com/quorumcontrol/dgit/tupelo/repotree"
+	"github.com/quorumcontrol/dgit/tupelo/teamtree"
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+// resubscribeDelay is how long watches wait before subscribing again after
+// the tupelo subscription ends, which it does on any error
+const resubscribeDelay = 5 * time.Second
+
+// RepoUpdate is a new tip of a watched repo chaintree
+type RepoUpdate struct {
+	Repo string
+	Tip  cid.Cid
+	// Previous is the repo tree as of the last update
+	Previous *repotree.RepoTree
+	Tree     *repotree.RepoTree
+	// Refs are the refs changed since the last update, which is empty when
+	// the update changed something else like the repo's teams
+	Refs []*repotree.RefDiff
+}
+
+// WatchRepo subscribes to new tips of the repo chaintree and calls fn with
+// each one, until ctx is done or fn returns an error
+func (c *Client) WatchRepo(ctx context.Context, repoName string, fn func(*RepoUpdate) error) error {
+	current, err := c.FindRepoTree(ctx, repoName)
+	if err != nil {
+		return err
+	}
+
+	refs, err := current.Refs(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.watchTree(ctx, current.Tree, func(latest *tree.Tree) error {
+		next := &repotree.RepoTree{Tree: latest}
+
+		nextRefs, err := next.Refs(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = fn(&RepoUpdate{
+			Repo:     repoName,
+			Tip:      next.ChainTree().Tip(),
+			Previous: current,
+			Tree:     next,
+			Refs:     repotree.DiffRefs(refs, nextRefs),
+		})
+		if err != nil {
+			return err
+		}
+
+		current, refs = next, nextRefs
+		return nil
+	})
+}
+
+// WatchRepoEvents watches the repo and the chaintrees of its teams and of
+// the teams nested in them, and calls fn with the hook payload of each
+// change, one at a time, until ctx is done or fn returns an error
+func (c *Client) WatchRepoEvents(ctx context.Context, repoName string, fn func(*hooks.Payload) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	current, err := c.FindRepoTree(ctx, repoName)
+	if err != nil {
+		return err
+	}
+
+	var (
+		lock     sync.Mutex
+		watchErr error
+	)
+
+	// emit serializes calls to fn from the repo and team watches, and stops
+	// all of them on the first error
+	emit := func(payload *hooks.Payload) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if watchErr != nil {
+			return watchErr
+		}
+		if err := fn(payload); err != nil {
+			watchErr = err
+			cancel()
+			return err
+		}
+		return nil
+	}
+
+	currentTree := func() *repotree.RepoTree {
+		lock.Lock()
+		defer lock.Unlock()
+		return current
+	}
+
+	teamPayload := func(t *tree.Tree) (*hooks.Payload, error) {
+		teams, err := teamsPayload(ctx, currentTree())
+		if err != nil {
+			return nil, err
+		}
+
+		return &hooks.Payload{
+			Event: hooks.TeamChanged,
+			Repo:  repoName,
+			Did:   t.Did(),
+			Tip:   t.ChainTree().Tip().String(),
+			Time:  time.Now(),
+			Teams: teams,
+		}, nil
+	}
+
+	var (
+		teamsLock sync.Mutex
+		watched   map[string]bool
+		stopTeams = func() {}
+		// watchTeams watches every team reached from the repo's teams,
+		// restarting the watches when the set of teams changed
+		watchTeams func(repoTree *repotree.RepoTree) error
+	)
+	watchTeams = func(repoTree *repotree.RepoTree) error {
+		teamsLock.Lock()
+		defer teamsLock.Unlock()
+
+		teams, err := nestedTeams(ctx, repoTree)
+		if err != nil {
+			return err
+		}
+
+		dids := make(map[string]bool, len(teams))
+		for _, team := range teams {
+			dids[team.Did()] = true
+		}
+		if reflect.DeepEqual(dids, watched) {
+			return nil
+		}
+
+		stopTeams()
+		var teamsCtx context.Context
+		teamsCtx, stopTeams = context.WithCancel(ctx)
+		watched = dids
+
+		for _, team := range teams {
+			go func(team *teamtree.TeamTree) {
+				err := c.watchTree(teamsCtx, team.Tree, func(latest *tree.Tree) error {
+					payload, err := teamPayload(latest)
+					if err != nil {
+						return err
+					}
+					if err := emit(payload); err != nil {
+						return err
+					}
+					// the change may have included or removed teams
+					return watchTeams(currentTree())
+				})
+				if err != nil && teamsCtx.Err() == nil {
+					log.Errorf("error watching team %s of %s: %v", team.Name(), repoName, err)
+				}
+			}(team)
+		}
+		return nil
+	}
+
+	if err := watchTeams(current); err != nil {
+		return err
+	}
+
+	err = c.WatchRepo(ctx, repoName, func(update *RepoUpdate) error {
+		lock.Lock()
+		current = update.Tree
+		lock.Unlock()
+
+		base := hooks.Payload{
+			Repo: repoName,
+			Did:  update.Tree.Did(),
+			Tip:  update.Tip.String(),
+			Time: time.Now(),
+		}
+
+		if len(update.Refs) > 0 {
+			payload := base
+			payload.Event = hooks.RefUpdated
+			for _, diff := range update.Refs {
+				payload.Refs = append(payload.Refs, &hooks.Ref{
+					Name: diff.Name.String(),
+					Old:  diff.Old.String(),
+					New:  diff.New.String(),
+				})
+			}
+			if err := emit(&payload); err != nil {
+				return err
+			}
+		}
+
+		changed, err := repotree.DiffConfig(ctx, update.Previous, update.Tree)
+		if err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			payload := base
+			payload.Event = hooks.RepoConfigChanged
+			payload.Changed = changed
+			if err := emit(&payload); err != nil {
+				return err
+			}
+		}
+
+		teamsDiffer, err := repotree.TeamsDiffer(ctx, update.Previous, update.Tree)
+		if err != nil {
+			return err
+		}
+		if teamsDiffer {
+			if err := watchTeams(update.Tree); err != nil {
+				return err
+			}
+
+			payload, err := teamPayload(update.Tree.Tree)
+			if err != nil {
+				return err
+			}
+			if err := emit(payload); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	teamsLock.Lock()
+	stopTeams()
+	teamsLock.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if watchErr != nil {
+		return watchErr
+	}
+	return err
+}
+
+// nestedTeams returns the repo's teams and the teams nested in them, each
+// once
+func nestedTeams(ctx context.Context, repoTree *repotree.RepoTree) ([]*teamtree.TeamTree, error) {
+	teams, err := repoTree.Teams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	nested := []*teamtree.TeamTree{}
+	for _, team := range teams {
+		trees, err := team.Tree.Nested(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range trees {
+			if !seen[t.Did()] {
+				seen[t.Did()] = true
+				nested = append(nested, t)
+			}
+		}
+	}
+
+	return nested, nil
+}
+
+// teamsPayload describes all teams of the repo for team-changed hooks, with
+// the members of nested teams expanded
+func teamsPayload(ctx context.Context, repoTree *repotree.RepoTree) ([]*hooks.Team, error) {
+	teams, err := repoTree.Teams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := make([]*hooks.Team, len(teams))
+	for i, team := range teams {
+		members, err := team.Tree.ExpandMembers(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		memberTeams, err := team.Tree.ListMemberTeams(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		invitations, err := team.Tree.Invitations(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		invited := make([]string, len(invitations))
+		for j, invitation := range invitations {
+			invited[j] = invitation.Username
+		}
+
+		payload[i] = &hooks.Team{
+			Name:    team.Name,
+			Role:    team.Role.String(),
+			Members: members.Names(),
+			Teams:   memberTeams.Names(),
+			Invited: invited,
+		}
+	}
+
+	return payload, nil
+}
+
+// watchTree subscribes to new tips of the chaintree and calls fn with the
+// latest tree for each one, until ctx is done or fn returns an error
+func (c *Client) watchTree(ctx context.Context, t *tree.Tree, fn func(*tree.Tree) error) error {
+	did := t.Did()
+	tip := t.ChainTree().Tip()
+
+	for {
+		subCtx, cancel := context.WithCancel(ctx)
+		proofs := make(chan *gossip.Proof, 1)
+		err := c.Tupelo.SubscribeToDid(subCtx, did, proofs)
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		// the subscription sends nil once it ends, which it only does after
+		// any proof it is sending was received
+		ended := false
+		stop := func() {
+			cancel()
+			for !ended {
+				ended = <-proofs == nil
+			}
+		}
+
+		for proof := range proofs {
+			if proof == nil {
+				ended = true
+				break
+			}
+
+			proofTip, err := cid.Cast(proof.Tip)
+			if err != nil {
+				stop()
+				return fmt.Errorf("error casting tip of %s: %w", did, err)
+			}
+			if proofTip.Equals(tip) {
+				continue
+			}
+
+			latest, err := tree.Find(ctx, c.Tupelo, did)
+			if err != nil {
+				stop()
+				return err
+			}
+
+			if err := fn(latest); err != nil {
+				stop()
+				return err
+			}
+
+			tip = latest.ChainTree().Tip()
+		}
+		stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(resubscribeDelay):
+			log.Warnf("subscription to %s ended, subscribing again", did)
+		}
+	}
+}
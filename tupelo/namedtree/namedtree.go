@@ -5,12 +5,16 @@ import (
 	"crypto/ecdsa"
 	"strings"
 
+	logging "github.com/ipfs/go-log"
+
 	"github.com/quorumcontrol/dgit/tupelo/tree"
 	"github.com/quorumcontrol/messages/v2/build/go/transactions"
 	"github.com/quorumcontrol/tupelo/sdk/consensus"
 	tupelo "github.com/quorumcontrol/tupelo/sdk/gossip/client"
 )
 
+var log = logging.Logger("decentragit.namedtree")
+
 var ErrNotFound = tree.ErrNotFound
 
 type Generator struct {
@@ -0,0 +1,146 @@
+package namedtree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+)
+
+// named trees (users and orgs) namespace repos in a map of repo name to
+// repo did, repos that have moved leave an entry in the redirects map
+// pointing at their new owner/name
+var reposMapPath = []string{"repos"}
+
+var redirectsMapPath = []string{"redirects"}
+
+func (t *NamedTree) AddRepo(ctx context.Context, ownerKey *ecdsa.PrivateKey, reponame string, did string) error {
+	log.Debugf("adding repo %s (%s) to %s (%s)", reponame, did, t.Name(), t.Did())
+
+	txns, err := t.addRepoTxns(ctx, reponame, did)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), ownerKey, txns)
+	return err
+}
+
+// RenameRepo moves a repo to a new name in a single transaction, leaving a
+// redirect at the old name
+func (t *NamedTree) RenameRepo(ctx context.Context, ownerKey *ecdsa.PrivateKey, oldName string, newName string, did string) error {
+	log.Debugf("renaming repo %s to %s (%s) in %s (%s)", oldName, newName, did, t.Name(), t.Did())
+
+	txns, err := t.addRepoTxns(ctx, newName, did)
+	if err != nil {
+		return err
+	}
+
+	removeTxns, err := t.removeRepoTxns(oldName, t.Name()+"/"+newName)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), ownerKey, append(txns, removeTxns...))
+	return err
+}
+
+// RemoveRepo removes a repo from the repos map. If redirect is set to a
+// full repo name, lookups of the old name will follow it.
+func (t *NamedTree) RemoveRepo(ctx context.Context, ownerKey *ecdsa.PrivateKey, reponame string, redirect string) error {
+	log.Debugf("removing repo %s from %s (%s), redirect: %s", reponame, t.Name(), t.Did(), redirect)
+
+	txns, err := t.removeRepoTxns(reponame, redirect)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), ownerKey, txns)
+	return err
+}
+
+func (t *NamedTree) Repos(ctx context.Context) (map[string]string, error) {
+	return t.stringMap(ctx, reposMapPath)
+}
+
+// Redirects returns a map of old repo names to the full name of the repo
+// they moved to
+func (t *NamedTree) Redirects(ctx context.Context) (map[string]string, error) {
+	return t.stringMap(ctx, redirectsMapPath)
+}
+
+func (t *NamedTree) addRepoTxns(ctx context.Context, reponame string, did string) ([]*transactions.Transaction, error) {
+	repoTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(reposMapPath, reponame), "/"), did)
+	if err != nil {
+		return nil, err
+	}
+
+	txns := []*transactions.Transaction{repoTxn}
+
+	// a repo taking over a name replaces any redirect left there
+	redirects, err := t.Redirects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := redirects[reponame]; ok {
+		redirectTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(redirectsMapPath, reponame), "/"), nil)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, redirectTxn)
+	}
+
+	return txns, nil
+}
+
+func (t *NamedTree) removeRepoTxns(reponame string, redirect string) ([]*transactions.Transaction, error) {
+	repoTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(reposMapPath, reponame), "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	txns := []*transactions.Transaction{repoTxn}
+
+	if redirect != "" {
+		redirectTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(redirectsMapPath, reponame), "/"), redirect)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, redirectTxn)
+	}
+
+	return txns, nil
+}
+
+func (t *NamedTree) stringMap(ctx context.Context, mapPath []string) (map[string]string, error) {
+	path := append([]string{"tree", "data"}, mapPath...)
+	valMap := make(map[string]string)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return valMap, nil
+	}
+
+	valMapUncast, ok := valUncast.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
+	}
+
+	for k, v := range valMapUncast {
+		if v == nil {
+			continue
+		}
+		vstr, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("key %s at path %v is %T, expected string", k, path, v)
+		}
+		valMap[k] = vstr
+	}
+
+	return valMap, nil
+}
@@ -0,0 +1,136 @@
+package orgtree
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+	tupelo "github.com/quorumcontrol/tupelo/sdk/gossip/client"
+
+	"github.com/quorumcontrol/dgit/tupelo/namedtree"
+	"github.com/quorumcontrol/dgit/tupelo/teamtree"
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+// OrgTree is a named tree like a user, but owned by a team of users
+// rather than a single key
+type OrgTree struct {
+	*namedtree.NamedTree
+}
+
+var log = logging.Logger("decentragit.orgtree")
+
+const orgSalt = "decentragit-org-v0"
+
+var namedTreeGen *namedtree.Generator
+
+var teamPath = []string{"team"}
+
+var ErrNotFound = tree.ErrNotFound
+
+func init() {
+	namedTreeGen = &namedtree.Generator{Namespace: orgSalt}
+}
+
+type Options struct {
+	Name    string
+	Tupelo  *tupelo.Client
+	Members teamtree.Members
+}
+
+func Did(name string) (string, error) {
+	return namedTreeGen.Did(name)
+}
+
+func Find(ctx context.Context, name string, client *tupelo.Client) (*OrgTree, error) {
+	namedTreeGen.Client = client
+	namedTree, err := namedTreeGen.Find(ctx, name)
+	if err == namedtree.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &OrgTree{namedTree}, nil
+}
+
+func Create(ctx context.Context, opts *Options) (*OrgTree, error) {
+	team, err := teamtree.Create(ctx, &teamtree.Options{
+		Name:    opts.Name + " org team",
+		Tupelo:  opts.Tupelo,
+		Members: opts.Members,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	teamTxn, err := chaintree.NewSetDataTransaction(strings.Join(teamPath, "/"), team.Did())
+	if err != nil {
+		return nil, err
+	}
+
+	namedTree, err := namedTreeGen.Create(ctx, &namedtree.Options{
+		Name:           opts.Name,
+		Tupelo:         opts.Tupelo,
+		Owners:         []string{team.Did()},
+		AdditionalTxns: []*transactions.Transaction{teamTxn},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("created org %s (%s) owned by team %s", opts.Name, namedTree.Did(), team.Did())
+
+	return &OrgTree{namedTree}, nil
+}
+
+// Team returns the team owning the org, its members administer the org
+// and its repos
+func (t *OrgTree) Team(ctx context.Context) (*teamtree.TeamTree, error) {
+	path := append([]string{"tree", "data"}, teamPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return nil, teamtree.ErrNotFound
+	}
+	teamDid, ok := valUncast.(string)
+	if !ok {
+		return nil, fmt.Errorf("org %s team is not a did string, got %T", t.Name(), valUncast)
+	}
+	return teamtree.Find(ctx, t.Tupelo(), teamDid)
+}
+
+// IsMember returns true if addr owns the user tree of any org team member
+func (t *OrgTree) IsMember(ctx context.Context, addr string) (bool, error) {
+	team, err := t.Team(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	members, err := team.ExpandMembers(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, member := range members {
+		memberTree, err := tree.Find(ctx, t.Tupelo(), member.Did())
+		if err != nil {
+			return false, err
+		}
+
+		isOwner, err := memberTree.IsOwner(ctx, addr)
+		if err != nil {
+			return false, err
+		}
+		if isOwner {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,18 @@
+package orgtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+func TestOrgDidDiffersFromUserDid(t *testing.T) {
+	orgDid, err := Did("quorumcontrol")
+	require.Nil(t, err)
+	userDid, err := usertree.Did("quorumcontrol")
+	require.Nil(t, err)
+
+	require.NotEqual(t, orgDid, userDid)
+}
@@ -0,0 +1,144 @@
+package repotree
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// RefDiff is a ref which changed between two states of a repo
+type RefDiff struct {
+	Name plumbing.ReferenceName
+	// Old is plumbing.ZeroHash when the ref was created
+	Old plumbing.Hash
+	// New is plumbing.ZeroHash when the ref was deleted
+	New plumbing.Hash
+}
+
+func (d *RefDiff) IsCreate() bool {
+	return d.Old.IsZero()
+}
+
+func (d *RefDiff) IsDelete() bool {
+	return d.New.IsZero()
+}
+
+// DiffRefs returns the refs which were created, updated or deleted going
+// from the old refs to the new ones, sorted by name
+func DiffRefs(old, new []*plumbing.Reference) []*RefDiff {
+	oldHashes := make(map[plumbing.ReferenceName]plumbing.Hash, len(old))
+	for _, ref := range old {
+		oldHashes[ref.Name()] = ref.Hash()
+	}
+
+	newHashes := make(map[plumbing.ReferenceName]plumbing.Hash, len(new))
+	for _, ref := range new {
+		newHashes[ref.Name()] = ref.Hash()
+	}
+
+	names := []string{}
+	for name := range oldHashes {
+		names = append(names, name.String())
+	}
+	for name := range newHashes {
+		if _, ok := oldHashes[name]; !ok {
+			names = append(names, name.String())
+		}
+	}
+	sort.Strings(names)
+
+	updates := []*RefDiff{}
+	for _, nameStr := range names {
+		name := plumbing.ReferenceName(nameStr)
+		if oldHashes[name] != newHashes[name] {
+			updates = append(updates, &RefDiff{
+				Name: name,
+				Old:  oldHashes[name],
+				New:  newHashes[name],
+			})
+		}
+	}
+
+	return updates
+}
+
+// DiffConfig returns the paths of the repo's config and metadata which
+// differ between the old and new repo states, such as config/protectedRefs
+func DiffConfig(ctx context.Context, old, new *RepoTree) ([]string, error) {
+	keys, err := dataKeys(ctx, []string{"config"}, old, new)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := [][]string{metadataPath}
+	for _, key := range keys {
+		paths = append(paths, []string{"config", key})
+	}
+
+	changed := []string{}
+	for _, path := range paths {
+		differs, err := dataDiffers(ctx, path, old, new)
+		if err != nil {
+			return nil, err
+		}
+		if differs {
+			changed = append(changed, strings.Join(path, "/"))
+		}
+	}
+
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// TeamsDiffer returns true if teams were added to or removed from the repo,
+// or their roles changed, between the old and new repo states. Changes to
+// team members are made in the teams' own chaintrees.
+func TeamsDiffer(ctx context.Context, old, new *RepoTree) (bool, error) {
+	for _, path := range [][]string{teamsMapPath, rolesMapPath} {
+		differs, err := dataDiffers(ctx, path, old, new)
+		if err != nil || differs {
+			return differs, err
+		}
+	}
+	return false, nil
+}
+
+// dataKeys returns the keys of the maps at path in any of the repo states
+func dataKeys(ctx context.Context, path []string, trees ...*RepoTree) ([]string, error) {
+	seen := make(map[string]bool)
+	keys := []string{}
+	for _, t := range trees {
+		valUncast, _, err := t.Resolve(ctx, append([]string{"tree", "data"}, path...))
+		if err != nil {
+			return nil, err
+		}
+		val, _ := valUncast.(map[string]interface{})
+		for key := range val {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func dataDiffers(ctx context.Context, path []string, old, new *RepoTree) (bool, error) {
+	dataPath := append([]string{"tree", "data"}, path...)
+
+	oldVal, _, err := old.Resolve(ctx, dataPath)
+	if err != nil {
+		return false, err
+	}
+
+	newVal, _, err := new.Resolve(ctx, dataPath)
+	if err != nil {
+		return false, err
+	}
+
+	return !reflect.DeepEqual(oldVal, newVal), nil
+}
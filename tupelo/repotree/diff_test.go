@@ -0,0 +1,120 @@
+package repotree
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/chaintree/nodestore"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+	"github.com/quorumcontrol/tupelo/sdk/consensus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+func TestDiffRefs(t *testing.T) {
+	oldHash := plumbing.NewHash("6ecf0ef2c2dffb796033e5a02219af86ec6584e5")
+	newHash := plumbing.NewHash("8a1d4f5c0e7a9b3c2d1e0f9a8b7c6d5e4f3a2b1c")
+
+	old := []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/feature", oldHash),
+		plumbing.NewHashReference("refs/heads/master", oldHash),
+		plumbing.NewHashReference("refs/tags/v1", oldHash),
+	}
+	new := []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/develop", newHash),
+		plumbing.NewHashReference("refs/heads/master", newHash),
+		plumbing.NewHashReference("refs/tags/v1", oldHash),
+	}
+
+	updates := DiffRefs(old, new)
+	require.Len(t, updates, 3)
+
+	require.Equal(t, plumbing.ReferenceName("refs/heads/develop"), updates[0].Name)
+	require.True(t, updates[0].IsCreate())
+	require.Equal(t, newHash, updates[0].New)
+
+	require.Equal(t, plumbing.ReferenceName("refs/heads/feature"), updates[1].Name)
+	require.True(t, updates[1].IsDelete())
+	require.Equal(t, oldHash, updates[1].Old)
+
+	require.Equal(t, plumbing.ReferenceName("refs/heads/master"), updates[2].Name)
+	require.Equal(t, oldHash, updates[2].Old)
+	require.Equal(t, newHash, updates[2].New)
+
+	require.Empty(t, DiffRefs(new, new))
+}
+
+// testDataTree returns a repo tree with the data set in a single block
+func testDataTree(t *testing.T, ctx context.Context, data map[string]interface{}) *RepoTree {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+
+	chainTree, err := consensus.NewSignedChainTree(ctx, key.PublicKey, nodestore.MustMemoryStore(ctx))
+	require.Nil(t, err)
+
+	txns := []*transactions.Transaction{}
+	for path, val := range data {
+		txn, err := chaintree.NewSetDataTransaction(path, val)
+		require.Nil(t, err)
+		txns = append(txns, txn)
+	}
+
+	block, err := consensus.SignBlock(ctx, &chaintree.BlockWithHeaders{
+		Block: chaintree.Block{Transactions: txns},
+	}, key)
+	require.Nil(t, err)
+
+	valid, err := chainTree.ChainTree.ProcessBlock(ctx, block)
+	require.Nil(t, err)
+	require.True(t, valid)
+
+	return &RepoTree{tree.New("test/repo", chainTree, nil)}
+}
+
+func TestDiffConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	old := testDataTree(t, ctx, map[string]interface{}{
+		"name":                 "test/repo",
+		"config/objectStorage": map[string]interface{}{"type": "siaskynet"},
+		"config/protectedRefs": map[string]interface{}{"refs/heads/master": map[string]interface{}{"forcePush": false}},
+		"metadata":             map[string]interface{}{"description": "old"},
+		"refs/heads/master":    "6ecf0ef2c2dffb796033e5a02219af86ec6584e5",
+		"teams":                map[string]interface{}{"default": "did:tupelo:0x1"},
+	})
+	new := testDataTree(t, ctx, map[string]interface{}{
+		"name":                 "test/repo",
+		"config/objectStorage": map[string]interface{}{"type": "siaskynet"},
+		"config/archived":      true,
+		"metadata":             map[string]interface{}{"description": "new"},
+		"refs/heads/master":    "918c48b83bd081e863dbe1b80f8998f058cd8294",
+		"teams":                map[string]interface{}{"default": "did:tupelo:0x1"},
+	})
+
+	changed, err := DiffConfig(ctx, old, new)
+	require.Nil(t, err)
+	require.Equal(t, []string{"config/archived", "config/protectedRefs", "metadata"}, changed)
+
+	changed, err = DiffConfig(ctx, new, new)
+	require.Nil(t, err)
+	require.Empty(t, changed)
+
+	differs, err := TeamsDiffer(ctx, old, new)
+	require.Nil(t, err)
+	require.False(t, differs)
+
+	withRole := testDataTree(t, ctx, map[string]interface{}{
+		"name":  "test/repo",
+		"teams": map[string]interface{}{"default": "did:tupelo:0x1"},
+		"roles": map[string]interface{}{"default": "admin"},
+	})
+
+	differs, err = TeamsDiffer(ctx, old, withRole)
+	require.Nil(t, err)
+	require.True(t, differs)
+}
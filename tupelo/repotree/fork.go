@@ -0,0 +1,185 @@
+package repotree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+var (
+	parentPath            = []string{"config", "parent"}
+	objectStorageTypePath = []string{"config", "objectStorage", "type"}
+	objectsPath           = []string{"objects"}
+	refsPath              = []string{"refs"}
+)
+
+// forkBatchSize is how many object shards are copied per block, each shard
+// holds the links of up to 1/256th of the objects
+const forkBatchSize = 16
+
+// Fork creates the repo name (owner/repo) as a fork of parent. The fork
+// records the parent did in its config and starts with the parent's refs
+// and object links, so no objects are uploaded again.
+func Fork(ctx context.Context, parent *RepoTree, name string, ownerKey *ecdsa.PrivateKey) (*RepoTree, error) {
+	storageType, err := parent.ObjectStorageType(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fork, err := Create(ctx, &Options{
+		Name:              name,
+		Tupelo:            parent.Tupelo(),
+		ObjectStorageType: storageType,
+		Parent:            parent.Did(),
+	}, ownerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	txns, err := parent.objectTxns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for len(txns) > 0 {
+		batch := txns
+		if len(batch) > forkBatchSize {
+			batch = txns[:forkBatchSize]
+		}
+		txns = txns[len(batch):]
+
+		_, err = fork.Tupelo().PlayTransactions(ctx, fork.ChainTree(), ownerKey, batch)
+		if err != nil {
+			return nil, fmt.Errorf("error copying objects of %s: %w", parent.Did(), err)
+		}
+	}
+
+	// refs are copied last so they never point at missing objects
+	refTxns, err := parent.refTxns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(refTxns) > 0 {
+		_, err = fork.Tupelo().PlayTransactions(ctx, fork.ChainTree(), ownerKey, refTxns)
+		if err != nil {
+			return nil, fmt.Errorf("error copying refs of %s: %w", parent.Did(), err)
+		}
+	}
+
+	log.Infof("forked %s to %s (%s)", parent.Did(), name, fork.Did())
+
+	return fork, nil
+}
+
+// Parent returns the repo this repo was forked from, or nil if it isn't a
+// fork
+func (t *RepoTree) Parent(ctx context.Context) (*RepoTree, error) {
+	path := append([]string{"tree", "data"}, parentPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return nil, nil
+	}
+
+	did, ok := valUncast.(string)
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected string", path, valUncast)
+	}
+
+	parent, err := tree.Find(ctx, t.Tupelo(), did)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RepoTree{parent}, nil
+}
+
+// ObjectStorageType returns the object storage configured for the repo
+func (t *RepoTree) ObjectStorageType(ctx context.Context) (string, error) {
+	path := append([]string{"tree", "data"}, objectStorageTypePath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if valUncast == nil {
+		return DefaultObjectStorageType, nil
+	}
+
+	storageType, ok := valUncast.(string)
+	if !ok {
+		return "", fmt.Errorf("path %v is %T, expected string", path, valUncast)
+	}
+
+	return storageType, nil
+}
+
+// objectTxns returns a set data transaction per object shard, which copies
+// the stored object links (or object data for chaintree storage) as is
+func (t *RepoTree) objectTxns(ctx context.Context) ([]*transactions.Transaction, error) {
+	path := append([]string{"tree", "data"}, objectsPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return nil, nil
+	}
+
+	shards, ok := valUncast.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
+	}
+
+	shardNames := make([]string, 0, len(shards))
+	for shard := range shards {
+		shardNames = append(shardNames, shard)
+	}
+	sort.Strings(shardNames)
+
+	txns := make([]*transactions.Transaction, 0, len(shardNames))
+	for _, shard := range shardNames {
+		shardPath := append(append([]string{}, objectsPath...), shard)
+		objects, _, err := t.Resolve(ctx, append([]string{"tree", "data"}, shardPath...))
+		if err != nil {
+			return nil, err
+		}
+		if objects == nil {
+			continue
+		}
+
+		txn, err := chaintree.NewSetDataTransaction(strings.Join(shardPath, "/"), objects)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, txn)
+	}
+
+	return txns, nil
+}
+
+// refTxns returns a set data transaction per ref
+func (t *RepoTree) refTxns(ctx context.Context) ([]*transactions.Transaction, error) {
+	refs, err := t.Refs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txns := make([]*transactions.Transaction, len(refs))
+	for i, ref := range refs {
+		txns[i], err = chaintree.NewSetDataTransaction(ref.Name().String(), ref.Hash().String())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return txns, nil
+}
@@ -0,0 +1,145 @@
+package repotree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/chaintree/dag"
+)
+
+// RefChange is a value a ref held, as recorded by the chaintree block that
+// set it
+type RefChange struct {
+	// Hash is plumbing.ZeroHash when the block removed the ref
+	Hash   plumbing.Hash
+	Height uint64
+	// Timestamp is the updatedAt the block set, which is zero when the
+	// block changed the ref without setting it, such as a fork or a move
+	Timestamp time.Time
+	// Signers are the addresses of the keys that signed the block
+	Signers []string
+}
+
+func (c *RefChange) IsDelete() bool {
+	return c.Hash.IsZero()
+}
+
+// RefHistory walks the repo chaintree back from its current tip and returns
+// the values the ref held, newest first. limit caps the number of changes
+// returned, 0 returns all of them.
+func (t *RepoTree) RefHistory(ctx context.Context, name plumbing.ReferenceName, limit int) ([]*RefChange, error) {
+	refPath := append([]string{"tree", "data"}, strings.Split(name.String(), "/")...)
+
+	d := t.ChainTree().ChainTree.Dag
+	val, err := refAt(ctx, d, refPath)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []*RefChange{}
+	for limit == 0 || len(changes) < limit {
+		block := &chaintree.BlockWithHeaders{}
+		err = d.ResolveInto(ctx, []string{chaintree.ChainLabel, chaintree.ChainEndLabel}, block)
+		if errors.Is(err, format.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error resolving block of %s: %w", d.Tip, err)
+		}
+
+		prevVal := ""
+		var prev *dag.Dag
+		if block.PreviousTip != nil {
+			prev = d.WithNewTip(*block.PreviousTip)
+			prevVal, err = refAt(ctx, prev, refPath)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if val != prevVal {
+			change, err := newRefChange(ctx, d, prev, block, val)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+		}
+
+		if prev == nil {
+			break
+		}
+		d, val = prev, prevVal
+	}
+
+	return changes, nil
+}
+
+func newRefChange(ctx context.Context, d *dag.Dag, prev *dag.Dag, block *chaintree.BlockWithHeaders, val string) (*RefChange, error) {
+	change := &RefChange{
+		Height: block.Height,
+	}
+	if val != "" {
+		change.Hash = plumbing.NewHash(val)
+	}
+
+	if sigs, ok := block.Headers["signatures"].(map[string]interface{}); ok {
+		for addr := range sigs {
+			change.Signers = append(change.Signers, addr)
+		}
+		sort.Strings(change.Signers)
+	}
+
+	// pushes set updatedAt in the same block as the refs, other writes
+	// leave the previous value which would date the change wrongly
+	updatedAt, err := timestampAt(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		prevUpdatedAt, err := timestampAt(ctx, prev)
+		if err != nil {
+			return nil, err
+		}
+		if updatedAt.Equal(prevUpdatedAt) {
+			updatedAt = time.Time{}
+		}
+	}
+	change.Timestamp = updatedAt
+
+	return change, nil
+}
+
+func timestampAt(ctx context.Context, d *dag.Dag) (time.Time, error) {
+	updatedAt, _, err := d.Resolve(ctx, []string{"tree", "data", "updatedAt"})
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch ts := updatedAt.(type) {
+	case int64:
+		return time.Unix(ts, 0), nil
+	case uint64:
+		return time.Unix(int64(ts), 0), nil
+	case int:
+		return time.Unix(int64(ts), 0), nil
+	}
+	return time.Time{}, nil
+}
+
+func refAt(ctx context.Context, d *dag.Dag, refPath []string) (string, error) {
+	valUncast, remaining, err := d.Resolve(ctx, refPath)
+	if err != nil {
+		return "", err
+	}
+	if len(remaining) > 0 {
+		return "", nil
+	}
+	val, _ := valUncast.(string)
+	return val, nil
+}
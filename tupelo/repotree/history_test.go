@@ -0,0 +1,94 @@
+package repotree
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/ipfs/go-cid"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/chaintree/nodestore"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+	"github.com/quorumcontrol/tupelo/sdk/consensus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+func TestRefHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey).String()
+
+	chainTree, err := consensus.NewSignedChainTree(ctx, key.PublicKey, nodestore.MustMemoryStore(ctx))
+	require.Nil(t, err)
+
+	height := uint64(0)
+	play := func(data map[string]interface{}) {
+		txns := []*transactions.Transaction{}
+		for path, val := range data {
+			txn, err := chaintree.NewSetDataTransaction(path, val)
+			require.Nil(t, err)
+			txns = append(txns, txn)
+		}
+
+		var previousTip *cid.Cid
+		if height > 0 {
+			tip := chainTree.Tip()
+			previousTip = &tip
+		}
+
+		block, err := consensus.SignBlock(ctx, &chaintree.BlockWithHeaders{
+			Block: chaintree.Block{
+				PreviousTip:  previousTip,
+				Height:       height,
+				Transactions: txns,
+			},
+		}, key)
+		require.Nil(t, err)
+
+		valid, err := chainTree.ChainTree.ProcessBlock(ctx, block)
+		require.Nil(t, err)
+		require.True(t, valid)
+		height++
+	}
+
+	first := "6ecf0ef2c2dffb796033e5a02219af86ec6584e5"
+	second := "918c48b83bd081e863dbe1b80f8998f058cd8294"
+
+	play(map[string]interface{}{"name": "test/repo"})
+	play(map[string]interface{}{"refs/heads/master": first, "updatedAt": int64(100)})
+	play(map[string]interface{}{"refs/heads/other": first, "updatedAt": int64(200)})
+	play(map[string]interface{}{"refs/heads/master": second, "updatedAt": int64(300)})
+	play(map[string]interface{}{"refs/heads/master": nil, "updatedAt": int64(400)})
+	// a fork or move writes refs without updating updatedAt
+	play(map[string]interface{}{"refs/heads/master": first})
+
+	repoTree := &RepoTree{tree.New("test/repo", chainTree, nil)}
+
+	changes, err := repoTree.RefHistory(ctx, plumbing.Master, 0)
+	require.Nil(t, err)
+	require.Len(t, changes, 4)
+
+	require.Equal(t, plumbing.NewHash(first), changes[0].Hash)
+	require.True(t, changes[0].Timestamp.IsZero())
+	require.True(t, changes[1].IsDelete())
+	require.Equal(t, int64(400), changes[1].Timestamp.Unix())
+	require.Equal(t, plumbing.NewHash(second), changes[2].Hash)
+	require.Equal(t, plumbing.NewHash(first), changes[3].Hash)
+	require.Equal(t, int64(100), changes[3].Timestamp.Unix())
+	require.Equal(t, uint64(1), changes[3].Height)
+	require.Equal(t, []string{addr}, changes[3].Signers)
+
+	changes, err = repoTree.RefHistory(ctx, plumbing.Master, 2)
+	require.Nil(t, err)
+	require.Len(t, changes, 2)
+
+	changes, err = repoTree.RefHistory(ctx, plumbing.NewBranchReferenceName("missing"), 0)
+	require.Nil(t, err)
+	require.Empty(t, changes)
+}
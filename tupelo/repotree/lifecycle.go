@@ -0,0 +1,114 @@
+package repotree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+	tupelo "github.com/quorumcontrol/tupelo/sdk/gossip/client"
+
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+var archivedPath = []string{"config", "archived"}
+
+// Archived returns true if the repo has been archived
+func (t *RepoTree) Archived(ctx context.Context) (bool, error) {
+	path := append([]string{"tree", "data"}, archivedPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	if valUncast == nil {
+		return false, nil
+	}
+
+	archived, ok := valUncast.(bool)
+	if !ok {
+		return false, fmt.Errorf("path %v is %T, expected bool", path, valUncast)
+	}
+
+	return archived, nil
+}
+
+// Archive marks the repo read-only. Its refs can still be fetched, but the
+// reference storage rejects any further updates.
+func (t *RepoTree) Archive(ctx context.Context, key *ecdsa.PrivateKey) error {
+	if err := t.requireAdmin(ctx, key); err != nil {
+		return err
+	}
+
+	txn, err := chaintree.NewSetDataTransaction(strings.Join(archivedPath, "/"), true)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, []*transactions.Transaction{txn})
+	return err
+}
+
+// Delete removes the full repo name (owner/repo) from its owner and clears
+// the team ownership of the repo chaintree, so it can no longer be found or
+// written to. The key must administer the repo and be able to manage repos
+// of its owner.
+func Delete(ctx context.Context, client *tupelo.Client, name string, key *ecdsa.PrivateKey) error {
+	ownerName, reponame := splitName(name)
+	if ownerName == "" || reponame == "" {
+		return fmt.Errorf("repo names must be in the form owner/repo")
+	}
+
+	owner, err := FindOwner(ctx, ownerName, client)
+	if err != nil {
+		return err
+	}
+
+	ownerRepos, err := owner.Repos(ctx)
+	if err != nil {
+		return err
+	}
+	did, ok := ownerRepos[reponame]
+	if !ok || did == "" {
+		return fmt.Errorf("repo %s not found (%w)", name, ErrNotFound)
+	}
+
+	t, err := tree.Find(ctx, client, did)
+	if err != nil {
+		return err
+	}
+	repoTree := &RepoTree{t}
+
+	if err = repoTree.requireAdmin(ctx, key); err != nil {
+		return err
+	}
+
+	canManage, err := CanManageRepos(ctx, owner, crypto.PubkeyToAddress(key.PublicKey).String())
+	if err != nil {
+		return err
+	}
+	if !canManage {
+		return fmt.Errorf("can not delete repo %s, current user can not manage repos of %s", name, ownerName)
+	}
+
+	err = owner.RemoveRepo(ctx, key, reponame, "")
+	if err != nil {
+		return err
+	}
+
+	ownershipTxn, err := chaintree.NewSetOwnershipTransaction([]string{})
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PlayTransactions(ctx, repoTree.ChainTree(), key, []*transactions.Transaction{ownershipTxn})
+	if err != nil {
+		return fmt.Errorf("repo was removed from %s but its ownership could not be cleared: %w", ownerName, err)
+	}
+
+	log.Infof("deleted repo %s (%s)", name, did)
+
+	return nil
+}
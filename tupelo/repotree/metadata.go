@@ -0,0 +1,105 @@
+package repotree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+)
+
+var metadataPath = []string{"metadata"}
+
+// Metadata describes a repo for discovery
+type Metadata struct {
+	Description string
+	Topics      []string
+	Homepage    string
+	// License is an SPDX license identifier like MIT or Apache-2.0
+	License string
+	// Readme is the path of the readme file within the repo
+	Readme string
+}
+
+func (m *Metadata) IsEmpty() bool {
+	return m.Description == "" && len(m.Topics) == 0 && m.Homepage == "" && m.License == "" && m.Readme == ""
+}
+
+func (m *Metadata) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"description": m.Description,
+		"topics":      m.Topics,
+		"homepage":    m.Homepage,
+		"license":     m.License,
+		"readme":      m.Readme,
+	}
+}
+
+func metadataFromMap(m map[string]interface{}) (*Metadata, error) {
+	metadata := &Metadata{}
+	metadata.Description, _ = m["description"].(string)
+	metadata.Homepage, _ = m["homepage"].(string)
+	metadata.License, _ = m["license"].(string)
+	metadata.Readme, _ = m["readme"].(string)
+
+	var err error
+	metadata.Topics, err = toStringSlice(m["topics"])
+	if err != nil {
+		return nil, fmt.Errorf("metadata topics: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// ParseTopics splits a comma separated list of topics, topics are lower
+// case with dashes instead of spaces
+func ParseTopics(str string) []string {
+	topics := []string{}
+	for _, topic := range strings.Split(str, ",") {
+		topic = strings.Join(strings.Fields(strings.ToLower(topic)), "-")
+		if topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// Metadata returns the repo metadata, which is empty if it was never set
+func (t *RepoTree) Metadata(ctx context.Context) (*Metadata, error) {
+	path := append([]string{"tree", "data"}, metadataPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return &Metadata{}, nil
+	}
+
+	valMap, ok := valUncast.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
+	}
+
+	return metadataFromMap(valMap)
+}
+
+// SetMetadata replaces the repo metadata
+func (t *RepoTree) SetMetadata(ctx context.Context, key *ecdsa.PrivateKey, metadata *Metadata) error {
+	if err := t.requireAdmin(ctx, key); err != nil {
+		return err
+	}
+
+	txn, err := metadataTxn(metadata)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, []*transactions.Transaction{txn})
+	return err
+}
+
+func metadataTxn(metadata *Metadata) (*transactions.Transaction, error) {
+	return chaintree.NewSetDataTransaction(strings.Join(metadataPath, "/"), metadata.toMap())
+}
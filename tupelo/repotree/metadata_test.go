@@ -0,0 +1,32 @@
+package repotree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTopics(t *testing.T) {
+	require.Equal(t, []string{"git", "decentralized-storage"}, ParseTopics("Git, decentralized storage,,"))
+	require.Equal(t, []string{}, ParseTopics(""))
+}
+
+func TestMetadataFromMap(t *testing.T) {
+	metadata := &Metadata{
+		Description: "git with decentralized ownership",
+		Topics:      []string{"git", "tupelo"},
+		License:     "MIT",
+		Readme:      "README.md",
+	}
+
+	// topics come back from the chaintree as a generic list
+	m := metadata.toMap()
+	m["topics"] = []interface{}{"git", "tupelo"}
+
+	parsed, err := metadataFromMap(m)
+	require.Nil(t, err)
+	require.Equal(t, metadata, parsed)
+
+	_, err = metadataFromMap(map[string]interface{}{"topics": "git"})
+	require.NotNil(t, err)
+}
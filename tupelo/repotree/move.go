@@ -0,0 +1,182 @@
+package repotree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+	tupelo "github.com/quorumcontrol/tupelo/sdk/gossip/client"
+
+	"github.com/quorumcontrol/dgit/tupelo/orgtree"
+	"github.com/quorumcontrol/dgit/tupelo/teamtree"
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+// ErrNotManager is returned when moving a repo between owners the key can
+// not manage repos of both of. Repos can't be handed to another person.
+var ErrNotManager = errors.New("repos can only be moved between users and orgs you manage")
+
+// Move renames a repo and/or moves it to another user or org. from and to
+// are full repo names (owner/repo). The old name is left as a redirect so
+// existing clones keep working. The key must administer the repo and be
+// able to manage repos of both owners, which is checked before anything
+// else, so repos only move between users and orgs the same person
+// controls and the new owner never has to accept.
+//
+// Renames within an owner are a single transaction. Moves first make
+// the new owner a repo admin (see grantOwner), then add the repo to the new
+// owner and remove it from the old one, so the repo stays reachable if a
+// later step fails.
+func Move(ctx context.Context, client *tupelo.Client, from string, to string, key *ecdsa.PrivateKey) (*RepoTree, error) {
+	fromOwnerName, fromName := splitName(from)
+	toOwnerName, toName := splitName(to)
+	if fromOwnerName == "" || fromName == "" || toOwnerName == "" || toName == "" {
+		return nil, fmt.Errorf("repo names must be in the form owner/repo")
+	}
+
+	addr := crypto.PubkeyToAddress(key.PublicKey).String()
+
+	fromOwner, err := FindOwner(ctx, fromOwnerName, client)
+	if err != nil {
+		return nil, err
+	}
+
+	toOwner, err := FindOwner(ctx, toOwnerName, client)
+	if err != nil {
+		return nil, fmt.Errorf("user or org %s does not exist (%w)", toOwnerName, err)
+	}
+
+	for _, owner := range []Owner{fromOwner, toOwner} {
+		canManage, err := CanManageRepos(ctx, owner, addr)
+		if err != nil {
+			return nil, err
+		}
+		if !canManage {
+			return nil, fmt.Errorf("can not move repo %s to %s, you can not manage repos of %s: %w", from, to, owner.Name(), ErrNotManager)
+		}
+	}
+
+	fromRepos, err := fromOwner.Repos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	did, ok := fromRepos[fromName]
+	if !ok || did == "" {
+		return nil, fmt.Errorf("repo %s not found (%w)", from, ErrNotFound)
+	}
+
+	t, err := tree.Find(ctx, client, did)
+	if err != nil {
+		return nil, err
+	}
+	repoTree := &RepoTree{t}
+
+	if err = repoTree.requireAdmin(ctx, key); err != nil {
+		return nil, err
+	}
+
+	toRepos, err := toOwner.Repos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := toRepos[toName]; ok {
+		return nil, fmt.Errorf("repo %s already exists for %s", toName, toOwnerName)
+	}
+
+	if fromOwner.Did() == toOwner.Did() {
+		err = fromOwner.RenameRepo(ctx, key, fromName, toName, did)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err = repoTree.grantOwner(ctx, key, toOwner)
+		if err != nil {
+			return nil, fmt.Errorf("error making %s an admin of %s: %w", toOwnerName, from, err)
+		}
+
+		err = toOwner.AddRepo(ctx, key, toName, did)
+		if err != nil {
+			return nil, err
+		}
+
+		err = fromOwner.RemoveRepo(ctx, key, fromName, toOwnerName+"/"+toName)
+		if err != nil {
+			return nil, fmt.Errorf("repo was added to %s but could not be removed from %s: %w", toOwnerName, fromOwnerName, err)
+		}
+	}
+
+	if toName != fromName {
+		nameTxn, err := chaintree.NewSetDataTransaction("name", toName)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = client.PlayTransactions(ctx, repoTree.ChainTree(), key, []*transactions.Transaction{nameTxn})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log.Infof("moved repo %s to %s (%s)", from, to, repoTree.Did())
+
+	return &RepoTree{tree.New(toName, repoTree.ChainTree(), client)}, nil
+}
+
+// grantOwner makes the new owner of a moved repo an admin through a
+// repo team named after it, which includes the team of an org or has a user
+// as its member. The old owner's teams are left as they are.
+func (t *RepoTree) grantOwner(ctx context.Context, key *ecdsa.PrivateKey, owner Owner) error {
+	_, err := t.Team(ctx, owner.Name())
+	if err == teamtree.ErrNotFound {
+		_, err = t.AddTeam(ctx, key, owner.Name(), RoleAdmin, teamtree.Members{})
+	} else if err == nil {
+		err = t.SetTeamRole(ctx, key, owner.Name(), RoleAdmin)
+	}
+	if err != nil {
+		return err
+	}
+
+	team, err := t.Team(ctx, owner.Name())
+	if err != nil {
+		return err
+	}
+
+	if orgTree, ok := owner.(*orgtree.OrgTree); ok {
+		orgTeam, err := orgTree.Team(ctx)
+		if err != nil {
+			return err
+		}
+		// named like the "@org" team refs of team add
+		err = team.AddMemberTeams(ctx, key, teamtree.Members{teamtree.NewMember(orgTeam.Did(), "@"+orgTree.Name())})
+		if err != nil {
+			return err
+		}
+		return t.updateOwners(ctx, key)
+	}
+
+	members, err := team.ListMembers(ctx)
+	if err != nil {
+		return err
+	}
+	if members.IsMember(owner.Did()) {
+		return nil
+	}
+	err = team.AddMembers(ctx, key, teamtree.Members{owner})
+	if err != nil {
+		return err
+	}
+	return t.updateOwners(ctx, key)
+}
+
+func splitName(fullName string) (string, string) {
+	parts := strings.SplitN(fullName, "/", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
@@ -0,0 +1,111 @@
+package repotree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	tupelo "github.com/quorumcontrol/tupelo/sdk/gossip/client"
+
+	"github.com/quorumcontrol/dgit/tupelo/orgtree"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+// Owner is the namespace a repo lives under, either a user or an org
+type Owner interface {
+	Name() string
+	Did() string
+	Repos(ctx context.Context) (map[string]string, error)
+	Redirects(ctx context.Context) (map[string]string, error)
+	AddRepo(ctx context.Context, ownerKey *ecdsa.PrivateKey, reponame string, did string) error
+	RenameRepo(ctx context.Context, ownerKey *ecdsa.PrivateKey, oldName string, newName string, did string) error
+	RemoveRepo(ctx context.Context, ownerKey *ecdsa.PrivateKey, reponame string, redirect string) error
+}
+
+var _ Owner = (*usertree.UserTree)(nil)
+var _ Owner = (*orgtree.OrgTree)(nil)
+
+// ErrNameTaken is returned when a user or org is created under a name
+// another user or org already has
+var ErrNameTaken = errors.New("name is already taken")
+
+// nameLookup reports whether a kind of owner named name exists
+type nameLookup struct {
+	kind   string
+	exists func(ctx context.Context, name string) (bool, error)
+}
+
+// CheckNameAvailable returns ErrNameTaken if a user or an org is named name,
+// since users and orgs share the namespace of repo urls. It is checked
+// before creating either.
+func CheckNameAvailable(ctx context.Context, name string, client *tupelo.Client) error {
+	return checkNameAvailable(ctx, name, []nameLookup{
+		{
+			kind: "user",
+			exists: func(ctx context.Context, name string) (bool, error) {
+				_, err := usertree.Find(ctx, name, client)
+				if err == usertree.ErrNotFound {
+					return false, nil
+				}
+				return err == nil, err
+			},
+		},
+		{
+			kind: "org",
+			exists: func(ctx context.Context, name string) (bool, error) {
+				_, err := orgtree.Find(ctx, name, client)
+				if err == orgtree.ErrNotFound {
+					return false, nil
+				}
+				return err == nil, err
+			},
+		},
+	})
+}
+
+func checkNameAvailable(ctx context.Context, name string, lookups []nameLookup) error {
+	for _, lookup := range lookups {
+		exists, err := lookup.exists(ctx, name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%w: %s %s already exists", ErrNameTaken, lookup.kind, name)
+		}
+	}
+	return nil
+}
+
+// FindOwner looks up name as a user first and then as an org
+func FindOwner(ctx context.Context, name string, client *tupelo.Client) (Owner, error) {
+	userTree, err := usertree.Find(ctx, name, client)
+	if err == nil {
+		log.Debugf("user chaintree found for %s - %s", name, userTree.Did())
+		return userTree, nil
+	}
+	if err != usertree.ErrNotFound {
+		return nil, err
+	}
+
+	orgTree, err := orgtree.Find(ctx, name, client)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("org chaintree found for %s - %s", name, orgTree.Did())
+
+	return orgTree, nil
+}
+
+// CanManageRepos returns true if addr may add repos to the owner, meaning
+// it owns the user tree or is a member of the org
+func CanManageRepos(ctx context.Context, owner Owner, addr string) (bool, error) {
+	switch o := owner.(type) {
+	case *usertree.UserTree:
+		return o.IsOwner(ctx, addr)
+	case *orgtree.OrgTree:
+		return o.IsMember(ctx, addr)
+	default:
+		return false, fmt.Errorf("unknown repo owner type %T", owner)
+	}
+}
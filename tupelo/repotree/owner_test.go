@@ -0,0 +1,49 @@
+package repotree
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckNameAvailable(t *testing.T) {
+	ctx := context.Background()
+
+	names := func(kind string, taken ...string) nameLookup {
+		return nameLookup{
+			kind: kind,
+			exists: func(_ context.Context, name string) (bool, error) {
+				for _, n := range taken {
+					if n == name {
+						return true, nil
+					}
+				}
+				return false, nil
+			},
+		}
+	}
+	lookups := []nameLookup{names("user", "alice"), names("org", "acme")}
+
+	require.Nil(t, checkNameAvailable(ctx, "bob", lookups))
+
+	// a user can't take an org's name, nor an org a user's
+	err := checkNameAvailable(ctx, "acme", lookups)
+	require.True(t, errors.Is(err, ErrNameTaken))
+	require.Contains(t, err.Error(), "org acme already exists")
+
+	err = checkNameAvailable(ctx, "alice", lookups)
+	require.True(t, errors.Is(err, ErrNameTaken))
+	require.Contains(t, err.Error(), "user alice already exists")
+
+	lookupErr := errors.New("tupelo unavailable")
+	failing := nameLookup{
+		kind: "user",
+		exists: func(context.Context, string) (bool, error) {
+			return false, lookupErr
+		},
+	}
+	err = checkNameAvailable(ctx, "bob", []nameLookup{failing, names("org")})
+	require.Equal(t, lookupErr, err)
+}
@@ -0,0 +1,295 @@
+package repotree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+var protectedRefsPath = []string{"config", "protectedRefs"}
+
+var ErrRefProtected = errors.New("protected ref")
+
+var ErrProtectionRuleNotFound = errors.New("protection rule not found")
+
+// ProtectionRule restricts how the refs matching Pattern can be updated.
+// Pattern is matched with path.Match against the full ref name, so
+// refs/heads/release/* protects every release branch.
+type ProtectionRule struct {
+	Pattern     string
+	NoForcePush bool
+	NoDelete    bool
+	// AllowedPushers are dg usernames, when set updates must be pushed with
+	// a key owning one of these users. Commit signatures aren't checked.
+	AllowedPushers []string
+	// AllowedTeams are repo team names, when set the pusher must be a member
+	// of one of these teams
+	AllowedTeams []string
+}
+
+// RefUpdate describes a pending reference change checked against the
+// protection rules. New is nil when the reference is being removed.
+type RefUpdate struct {
+	Name   plumbing.ReferenceName
+	Old    *plumbing.Reference
+	New    *plumbing.Reference
+	Signer string
+	// IsFastForward reports whether New descends from Old, it is only
+	// called when a matching rule forbids force pushes
+	IsFastForward func() (bool, error)
+}
+
+// NormalizeRefPattern expands short branch names like "main" to
+// "refs/heads/main"
+func NormalizeRefPattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if strings.HasPrefix(pattern, "refs/") {
+		return pattern
+	}
+	return "refs/heads/" + pattern
+}
+
+func (r *ProtectionRule) Matches(name plumbing.ReferenceName) bool {
+	matched, err := path.Match(r.Pattern, name.String())
+	return err == nil && matched
+}
+
+func (r *ProtectionRule) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"pattern":        r.Pattern,
+		"noForcePush":    r.NoForcePush,
+		"noDelete":       r.NoDelete,
+		"allowedPushers": r.AllowedPushers,
+		"allowedTeams":   r.AllowedTeams,
+	}
+}
+
+func protectionRuleFromMap(m map[string]interface{}) (*ProtectionRule, error) {
+	pattern, ok := m["pattern"].(string)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("protection rule pattern is %T, expected string", m["pattern"])
+	}
+
+	rule := &ProtectionRule{Pattern: pattern}
+	rule.NoForcePush, _ = m["noForcePush"].(bool)
+	rule.NoDelete, _ = m["noDelete"].(bool)
+
+	var err error
+	rule.AllowedPushers, err = toStringSlice(m["allowedPushers"])
+	if err != nil {
+		return nil, fmt.Errorf("protection rule %s allowedPushers: %w", pattern, err)
+	}
+	rule.AllowedTeams, err = toStringSlice(m["allowedTeams"])
+	if err != nil {
+		return nil, fmt.Errorf("protection rule %s allowedTeams: %w", pattern, err)
+	}
+
+	return rule, nil
+}
+
+func toStringSlice(valUncast interface{}) ([]string, error) {
+	if valUncast == nil {
+		return nil, nil
+	}
+	vals, ok := valUncast.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("is %T, expected list", valUncast)
+	}
+	strs := make([]string, len(vals))
+	for i, v := range vals {
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("item %d is %T, expected string", i, v)
+		}
+		strs[i] = str
+	}
+	return strs, nil
+}
+
+// ProtectionRules returns the protection rules stored in the repo config
+func (t *RepoTree) ProtectionRules(ctx context.Context) ([]*ProtectionRule, error) {
+	path := append([]string{"tree", "data"}, protectedRefsPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return []*ProtectionRule{}, nil
+	}
+
+	vals, ok := valUncast.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected list", path, valUncast)
+	}
+
+	rules := make([]*ProtectionRule, len(vals))
+	for i, v := range vals {
+		ruleMap, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("protection rule %d is %T, expected map", i, v)
+		}
+		rules[i], err = protectionRuleFromMap(ruleMap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
+
+// SetProtectionRule adds the rule or replaces an existing rule with the
+// same pattern
+func (t *RepoTree) SetProtectionRule(ctx context.Context, key *ecdsa.PrivateKey, rule *ProtectionRule) error {
+	if err := t.requireAdmin(ctx, key); err != nil {
+		return err
+	}
+
+	rules, err := t.ProtectionRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, existing := range rules {
+		if existing.Pattern == rule.Pattern {
+			rules[i] = rule
+			replaced = true
+		}
+	}
+	if !replaced {
+		rules = append(rules, rule)
+	}
+
+	return t.setProtectionRules(ctx, key, rules)
+}
+
+func (t *RepoTree) RemoveProtectionRule(ctx context.Context, key *ecdsa.PrivateKey, pattern string) error {
+	if err := t.requireAdmin(ctx, key); err != nil {
+		return err
+	}
+
+	rules, err := t.ProtectionRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	remaining := []*ProtectionRule{}
+	for _, rule := range rules {
+		if rule.Pattern != pattern {
+			remaining = append(remaining, rule)
+		}
+	}
+	if len(remaining) == len(rules) {
+		return ErrProtectionRuleNotFound
+	}
+
+	return t.setProtectionRules(ctx, key, remaining)
+}
+
+func (t *RepoTree) setProtectionRules(ctx context.Context, key *ecdsa.PrivateKey, rules []*ProtectionRule) error {
+	ruleMaps := make([]map[string]interface{}, len(rules))
+	for i, rule := range rules {
+		ruleMaps[i] = rule.toMap()
+	}
+
+	txn, err := chaintree.NewSetDataTransaction(strings.Join(protectedRefsPath, "/"), ruleMaps)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, []*transactions.Transaction{txn})
+	return err
+}
+
+// CheckRefUpdate returns an error wrapping ErrRefProtected if the update
+// violates any protection rule matching the ref
+func (t *RepoTree) CheckRefUpdate(ctx context.Context, update *RefUpdate) error {
+	rules, err := t.ProtectionRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		if !rule.Matches(update.Name) {
+			continue
+		}
+
+		if update.New == nil {
+			if rule.NoDelete {
+				return fmt.Errorf("%w: %s can not be deleted", ErrRefProtected, update.Name)
+			}
+		} else if rule.NoForcePush && update.Old != nil {
+			ff, err := update.IsFastForward()
+			if err != nil {
+				return err
+			}
+			if !ff {
+				return fmt.Errorf("%w: %s does not allow force pushes", ErrRefProtected, update.Name)
+			}
+		}
+
+		if len(rule.AllowedPushers) > 0 {
+			err = t.checkPushers(ctx, update, rule.AllowedPushers)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(rule.AllowedTeams) > 0 {
+			err = t.checkTeams(ctx, update, rule.AllowedTeams)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *RepoTree) checkPushers(ctx context.Context, update *RefUpdate, pushers []string) error {
+	for _, username := range pushers {
+		userTree, err := usertree.Find(ctx, username, t.Tupelo())
+		if err == usertree.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		isOwner, err := userTree.IsOwner(ctx, update.Signer)
+		if err != nil {
+			return err
+		}
+		if isOwner {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s can only be pushed by %s", ErrRefProtected, update.Name, strings.Join(pushers, ", "))
+}
+
+func (t *RepoTree) checkTeams(ctx context.Context, update *RefUpdate, allowedTeams []string) error {
+	teams, err := t.TeamsFor(ctx, update.Signer)
+	if err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		for _, allowed := range allowedTeams {
+			if team.Name == allowed {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: %s can only be updated by members of %s", ErrRefProtected, update.Name, strings.Join(allowedTeams, ", "))
+}
@@ -0,0 +1,37 @@
+package repotree
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRefPattern(t *testing.T) {
+	require.Equal(t, "refs/heads/main", NormalizeRefPattern("main"))
+	require.Equal(t, "refs/tags/*", NormalizeRefPattern("refs/tags/*"))
+}
+
+func TestProtectionRuleMatches(t *testing.T) {
+	rule := &ProtectionRule{Pattern: "refs/heads/release/*"}
+	require.True(t, rule.Matches(plumbing.ReferenceName("refs/heads/release/v1")))
+	require.False(t, rule.Matches(plumbing.ReferenceName("refs/heads/release/v1/hotfix")))
+	require.False(t, rule.Matches(plumbing.ReferenceName("refs/heads/main")))
+}
+
+func TestProtectionRuleFromMap(t *testing.T) {
+	rule, err := protectionRuleFromMap(map[string]interface{}{
+		"pattern":        "refs/heads/main",
+		"noForcePush":    true,
+		"allowedPushers": []interface{}{"alice"},
+	})
+	require.Nil(t, err)
+	require.Equal(t, &ProtectionRule{
+		Pattern:        "refs/heads/main",
+		NoForcePush:    true,
+		AllowedPushers: []string{"alice"},
+	}, rule)
+
+	_, err = protectionRuleFromMap(map[string]interface{}{"noDelete": true})
+	require.NotNil(t, err)
+}
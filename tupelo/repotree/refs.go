@@ -0,0 +1,109 @@
+package repotree
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/quorumcontrol/dgit/storage"
+)
+
+// Refs returns the refs stored in the repo sorted by name
+func (t *RepoTree) Refs(ctx context.Context) ([]*plumbing.Reference, error) {
+	refs := []*plumbing.Reference{}
+
+	var walk func(refPath []string) error
+	walk = func(refPath []string) error {
+		valUncast, _, err := t.Resolve(ctx, append([]string{"tree", "data"}, refPath...))
+		if err != nil {
+			return err
+		}
+
+		switch val := valUncast.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(val))
+			for key := range val {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				if err := walk(append(append([]string{}, refPath...), key)); err != nil {
+					return err
+				}
+			}
+		case string:
+			name := plumbing.ReferenceName(strings.Join(refPath, "/"))
+			refs = append(refs, plumbing.NewHashReference(name, plumbing.NewHash(val)))
+		}
+		return nil
+	}
+
+	if err := walk(refsPath); err != nil {
+		return nil, err
+	}
+
+	return refs, nil
+}
+
+// DefaultBranch returns the ref clones check out as HEAD: master if it
+// exists, otherwise the last ref by name. It returns "" for no refs.
+func DefaultBranch(refs []*plumbing.Reference) plumbing.ReferenceName {
+	var last plumbing.ReferenceName
+	for _, ref := range refs {
+		if ref.Name() == plumbing.Master {
+			return ref.Name()
+		}
+		if ref.Name() > last {
+			last = ref.Name()
+		}
+	}
+	return last
+}
+
+// PushHistory returns the push certificates of the given ref, oldest first
+func (t *RepoTree) PushHistory(ctx context.Context, name plumbing.ReferenceName) ([]*storage.PushCertificate, error) {
+	path := append([]string{"tree", "data"}, storage.PushHistoryPath(name)...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return nil, nil
+	}
+
+	history, ok := valUncast.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
+	}
+
+	keys := make([]string, 0, len(history))
+	for key := range history {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	certs := make([]*storage.PushCertificate, len(keys))
+	for i, key := range keys {
+		// each certificate is stored as a linked node
+		certUncast, _, err := t.Resolve(ctx, append(append([]string{}, path...), key))
+		if err != nil {
+			return nil, err
+		}
+
+		certMap, ok := certUncast.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("push certificate %s of %s is %T, expected map", key, name, certUncast)
+		}
+
+		certs[i], err = storage.PushCertificateFromMap(certMap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return certs, nil
+}
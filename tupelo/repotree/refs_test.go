@@ -0,0 +1,19 @@
+package repotree
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultBranch(t *testing.T) {
+	hash := plumbing.NewHash("6ecf0ef2c2dffb796033e5a02219af86ec6584e5")
+	main := plumbing.NewHashReference("refs/heads/main", hash)
+	master := plumbing.NewHashReference("refs/heads/master", hash)
+	feature := plumbing.NewHashReference("refs/heads/feature", hash)
+
+	require.Equal(t, plumbing.Master, DefaultBranch([]*plumbing.Reference{feature, master, main}))
+	require.Equal(t, main.Name(), DefaultBranch([]*plumbing.Reference{main, feature}))
+	require.Equal(t, plumbing.ReferenceName(""), DefaultBranch(nil))
+}
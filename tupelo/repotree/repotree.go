@@ -13,6 +13,7 @@ import (
 	"github.com/quorumcontrol/messages/v2/build/go/transactions"
 	tupelo "github.com/quorumcontrol/tupelo/sdk/gossip/client"
 
+	"github.com/quorumcontrol/dgit/tupelo/orgtree"
 	"github.com/quorumcontrol/dgit/tupelo/teamtree"
 	"github.com/quorumcontrol/dgit/tupelo/tree"
 	"github.com/quorumcontrol/dgit/tupelo/usertree"
@@ -33,32 +34,52 @@ type Options struct {
 	Tupelo            *tupelo.Client
 	Owners            []string
 	ObjectStorageType string
+	// Parent is the did of the repo this one was forked from
+	Parent   string
+	Metadata *Metadata
 }
 
 type RepoTree struct {
 	*tree.Tree
 }
 
+// maxRedirects bounds how many moved repo redirects Find follows
+const maxRedirects = 5
+
 func Find(ctx context.Context, repo string, client *tupelo.Client) (*RepoTree, error) {
+	return find(ctx, repo, client, 0)
+}
+
+func find(ctx context.Context, repo string, client *tupelo.Client, redirects int) (*RepoTree, error) {
 	log.Debugf("looking for repo %s", repo)
 
-	username := strings.Split(repo, "/")[0]
+	ownerName := strings.Split(repo, "/")[0]
 	reponame := strings.Join(strings.Split(repo, "/")[1:], "/")
 
-	userTree, err := usertree.Find(ctx, username, client)
+	owner, err := FindOwner(ctx, ownerName, client)
 	if err != nil {
 		return nil, err
 	}
-	log.Debugf("user chaintree found for %s - %s", username, userTree.Did())
 
-	userRepos, err := userTree.Repos(ctx)
+	ownerRepos, err := owner.Repos(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	repoDid, ok := userRepos[reponame]
+	repoDid, ok := ownerRepos[reponame]
 	if !ok || repoDid == "" {
-		return nil, ErrNotFound
+		ownerRedirects, err := owner.Redirects(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		target, ok := ownerRedirects[reponame]
+		if !ok || redirects >= maxRedirects {
+			return nil, ErrNotFound
+		}
+
+		log.Debugf("repo %s has moved to %s", repo, target)
+		return find(ctx, target, client, redirects+1)
 	}
 
 	t, err := tree.Find(ctx, client, repoDid)
@@ -73,48 +94,56 @@ func Find(ctx context.Context, repo string, client *tupelo.Client) (*RepoTree, e
 func Create(ctx context.Context, opts *Options, ownerKey *ecdsa.PrivateKey) (*RepoTree, error) {
 	log.Debugf("creating new repotree with options: %+v", opts)
 
-	username := strings.Split(opts.Name, "/")[0]
+	ownerName := strings.Split(opts.Name, "/")[0]
 	reponame := strings.Join(strings.Split(opts.Name, "/")[1:], "/")
 
-	userTree, err := usertree.Find(ctx, username, opts.Tupelo)
+	owner, err := FindOwner(ctx, ownerName, opts.Tupelo)
 	if err == usertree.ErrNotFound {
-		return nil, fmt.Errorf("user %s does not exist (%w)", username, err)
+		return nil, fmt.Errorf("user or org %s does not exist (%w)", ownerName, err)
 	}
 	if err != nil {
 		return nil, err
 	}
-	log.Debugf("user chaintree found for %s - %s", username, userTree.Did())
 
-	isOwner, err := userTree.IsOwner(ctx, crypto.PubkeyToAddress(ownerKey.PublicKey).String())
+	canManage, err := CanManageRepos(ctx, owner, crypto.PubkeyToAddress(ownerKey.PublicKey).String())
 	if err != nil {
 		return nil, err
 	}
-	if !isOwner {
-		return nil, fmt.Errorf("can not create repo %s, current user is not an owner of %s", opts.Name, username)
+	if !canManage {
+		return nil, fmt.Errorf("can not create repo %s, current user is not an owner of %s", opts.Name, ownerName)
 	}
 
-	userRepos, err := userTree.Repos(ctx)
+	ownerRepos, err := owner.Repos(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	_, ok := userRepos[reponame]
+	_, ok := ownerRepos[reponame]
 	if ok {
-		return nil, fmt.Errorf("repo %s already exists for %s", reponame, username)
+		return nil, fmt.Errorf("repo %s already exists for %s", reponame, ownerName)
 	}
 
-	if storage, found := os.LookupEnv("DGIT_OBJ_STORAGE"); found {
-		log.Warningf("[DEPRECATION] - DGIT_OBJ_STORAGE is deprecated, please use DG_OBJ_STORAGE")
-		opts.ObjectStorageType = storage
-	}
-	if storage, found := os.LookupEnv("DG_OBJ_STORAGE"); found {
-		opts.ObjectStorageType = storage
+	// forks share the object links of their parent, so they must keep its
+	// object storage type
+	if opts.Parent == "" {
+		if storage, found := os.LookupEnv("DGIT_OBJ_STORAGE"); found {
+			log.Warningf("[DEPRECATION] - DGIT_OBJ_STORAGE is deprecated, please use DG_OBJ_STORAGE")
+			opts.ObjectStorageType = storage
+		}
+		if storage, found := os.LookupEnv("DG_OBJ_STORAGE"); found {
+			opts.ObjectStorageType = storage
+		}
 	}
 	if opts.ObjectStorageType == "" {
 		opts.ObjectStorageType = DefaultObjectStorageType
 	}
 
-	config := map[string]map[string]string{"objectStorage": {"type": opts.ObjectStorageType}}
+	config := map[string]interface{}{
+		"objectStorage": map[string]string{"type": opts.ObjectStorageType},
+	}
+	if opts.Parent != "" {
+		config["parent"] = opts.Parent
+	}
 	configTxn, err := chaintree.NewSetDataTransaction("config", config)
 	if err != nil {
 		return nil, err
@@ -122,35 +151,46 @@ func Create(ctx context.Context, opts *Options, ownerKey *ecdsa.PrivateKey) (*Re
 
 	log.Debugf("using object storage type %s", opts.ObjectStorageType)
 
-	defaultTeam, err := teamtree.Create(ctx, &teamtree.Options{
-		Name:   opts.Name + " default team",
-		Tupelo: opts.Tupelo,
-		Members: teamtree.Members{
-			userTree,
-		},
-	})
+	defaultTeam, err := createDefaultTeam(ctx, opts, owner)
 	if err != nil {
 		return nil, err
 	}
-	teamTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(teamsMapPath, "default"), "/"), defaultTeam.Did())
+	teamTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(teamsMapPath, DefaultTeamName), "/"), defaultTeam.Did())
+	if err != nil {
+		return nil, err
+	}
+	roleTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(rolesMapPath, DefaultTeamName), "/"), string(RoleAdmin))
+	if err != nil {
+		return nil, err
+	}
+
+	txns := []*transactions.Transaction{configTxn, teamTxn, roleTxn}
+
+	if opts.Metadata != nil && !opts.Metadata.IsEmpty() {
+		metadataTxn, err := metadataTxn(opts.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, metadataTxn)
+	}
+
+	owners, err := writerDids(ctx, []*Team{{Name: DefaultTeamName, Role: RoleAdmin, Tree: defaultTeam}})
 	if err != nil {
 		return nil, err
 	}
 
 	t, err := tree.Create(ctx, &tree.Options{
-		Name:   reponame,
-		Tupelo: opts.Tupelo,
-		Owners: []string{
-			defaultTeam.Did(),
-		},
-		AdditionalTxns: []*transactions.Transaction{configTxn, teamTxn},
+		Name:           reponame,
+		Tupelo:         opts.Tupelo,
+		Owners:         owners,
+		AdditionalTxns: txns,
 	})
 	if err != nil {
 		return nil, err
 	}
 	log.Infof("created %s repo chaintree with did: %s", t.Name(), t.Did())
 
-	err = userTree.AddRepo(ctx, ownerKey, reponame, t.Did())
+	err = owner.AddRepo(ctx, ownerKey, reponame, t.Did())
 	if err != nil {
 		return nil, err
 	}
@@ -158,6 +198,39 @@ func Create(ctx context.Context, opts *Options, ownerKey *ecdsa.PrivateKey) (*Re
 	return &RepoTree{t}, nil
 }
 
+// createDefaultTeam makes the repo creator the only member of a new admin
+// team. For org repos the new team includes the org team instead, so every
+// org member administers them while the repo's team stays its own. Like
+// every repo team it is managed by the repo's admins, see setOwners.
+func createDefaultTeam(ctx context.Context, opts *Options, owner Owner) (*teamtree.TeamTree, error) {
+	teamOpts := &teamtree.Options{
+		Name:    opts.Name + " default team",
+		Tupelo:  opts.Tupelo,
+		Members: teamtree.Members{},
+		Owners:  []string{owner.Did()},
+		Managed: true,
+	}
+
+	if orgTree, ok := owner.(*orgtree.OrgTree); ok {
+		orgTeam, err := orgTree.Team(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// named like the "@org" team refs of team add
+		teamOpts.Teams = teamtree.Members{teamtree.NewMember(orgTeam.Did(), "@"+orgTree.Name())}
+
+		orgMembers, err := orgTeam.ExpandMembers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		teamOpts.Owners = orgMembers.Dids()
+	} else {
+		teamOpts.Members = teamtree.Members{owner}
+	}
+
+	return teamtree.Create(ctx, teamOpts)
+}
+
 func (t *RepoTree) Team(ctx context.Context, name string) (*teamtree.TeamTree, error) {
 	path := append(append([]string{"tree", "data"}, teamsMapPath...), name)
 	valUncast, _, err := t.Resolve(ctx, path)
@@ -0,0 +1,59 @@
+package repotree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role is the access a repo team grants to its members
+type Role string
+
+const (
+	RoleNone  Role = ""
+	RoleRead  Role = "read"
+	RoleWrite Role = "write"
+	RoleAdmin Role = "admin"
+)
+
+// DefaultRole is used for teams that were created before roles existed,
+// when every team member was a full owner of the repo
+const DefaultRole = RoleAdmin
+
+var roleRanks = map[Role]int{
+	RoleNone:  0,
+	RoleRead:  1,
+	RoleWrite: 2,
+	RoleAdmin: 3,
+}
+
+func ParseRole(str string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(str)))
+	if _, ok := roleRanks[role]; !ok || role == RoleNone {
+		return RoleNone, fmt.Errorf("invalid role %q, must be one of %s, %s or %s", str, RoleRead, RoleWrite, RoleAdmin)
+	}
+	return role, nil
+}
+
+func (r Role) String() string {
+	if r == RoleNone {
+		return "none"
+	}
+	return string(r)
+}
+
+// Includes returns true if r grants at least the access of other
+func (r Role) Includes(other Role) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
+
+func (r Role) CanRead() bool {
+	return r.Includes(RoleRead)
+}
+
+func (r Role) CanWrite() bool {
+	return r.Includes(RoleWrite)
+}
+
+func (r Role) CanAdmin() bool {
+	return r.Includes(RoleAdmin)
+}
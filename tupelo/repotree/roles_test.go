@@ -0,0 +1,27 @@
+package repotree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole("Write")
+	require.Nil(t, err)
+	require.Equal(t, RoleWrite, role)
+
+	_, err = ParseRole("")
+	require.NotNil(t, err)
+
+	_, err = ParseRole("owner")
+	require.NotNil(t, err)
+}
+
+func TestRoleIncludes(t *testing.T) {
+	require.True(t, RoleAdmin.CanWrite())
+	require.True(t, RoleWrite.CanRead())
+	require.False(t, RoleWrite.CanAdmin())
+	require.False(t, RoleRead.CanWrite())
+	require.False(t, RoleNone.CanRead())
+}
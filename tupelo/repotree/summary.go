@@ -0,0 +1,105 @@
+package repotree
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Summary is an overview of a repo's state
+type Summary struct {
+	Name          string    `json:"name"`
+	Did           string    `json:"did"`
+	DefaultBranch string    `json:"defaultBranch"`
+	ObjectStorage string    `json:"objectStorage"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+	TeamSize      int       `json:"teamSize"`
+	Archived      bool      `json:"archived"`
+}
+
+// Summary resolves the state of the repo, name is the full name it was
+// found under
+func (t *RepoTree) Summary(ctx context.Context, name string) (*Summary, error) {
+	refs, err := t.Refs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	storageType, err := t.ObjectStorageType(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt, err := t.UpdatedAt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	teamSize, err := t.TeamSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	archived, err := t.Archived(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Summary{
+		Name:          name,
+		Did:           t.Did(),
+		DefaultBranch: DefaultBranch(refs).Short(),
+		ObjectStorage: storageType,
+		UpdatedAt:     updatedAt,
+		TeamSize:      teamSize,
+		Archived:      archived,
+	}, nil
+}
+
+// UpdatedAt returns when a ref of the repo was last changed, or when the
+// repo was created if it was never pushed to
+func (t *RepoTree) UpdatedAt(ctx context.Context) (time.Time, error) {
+	for _, key := range []string{"updatedAt", "createdAt"} {
+		path := []string{"tree", "data", key}
+		valUncast, _, err := t.Resolve(ctx, path)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		switch val := valUncast.(type) {
+		case nil:
+			continue
+		case int64:
+			return time.Unix(val, 0), nil
+		case uint64:
+			return time.Unix(int64(val), 0), nil
+		case int:
+			return time.Unix(int64(val), 0), nil
+		default:
+			return time.Time{}, fmt.Errorf("path %v is %T, expected a unix timestamp", path, valUncast)
+		}
+	}
+
+	return time.Time{}, nil
+}
+
+// TeamSize returns the number of distinct members across all repo teams
+func (t *RepoTree) TeamSize(ctx context.Context) (int, error) {
+	teams, err := t.Teams(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	members := make(map[string]bool)
+	for _, team := range teams {
+		teamMembers, err := team.Tree.ExpandMembers(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, member := range teamMembers {
+			members[member.Did()] = true
+		}
+	}
+
+	return len(members), nil
+}
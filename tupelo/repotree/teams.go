@@ -0,0 +1,668 @@
+package repotree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+
+	"github.com/quorumcontrol/dgit/tupelo/teamtree"
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+	"github.com/quorumcontrol/dgit/tupelo/usertree"
+)
+
+const DefaultTeamName = "default"
+
+var rolesMapPath = []string{"roles"}
+
+var ErrNotAdmin = errors.New("only repo admins can manage teams")
+
+var ErrLastAdminTeam = errors.New("repo must have at least one admin team")
+
+var ErrLastAdmin = errors.New("repo must keep at least one admin, add another admin first")
+
+// Team is a named team of a repo along with the role it grants
+type Team struct {
+	Name string
+	Role Role
+	Tree *teamtree.TeamTree
+}
+
+// Teams returns all teams of the repo sorted by name
+func (t *RepoTree) Teams(ctx context.Context) ([]*Team, error) {
+	teamDids, err := t.stringMap(ctx, teamsMapPath)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := t.stringMap(ctx, rolesMapPath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(teamDids))
+	for name := range teamDids {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	teams := make([]*Team, len(names))
+	for i, name := range names {
+		role := DefaultRole
+		if roleStr, ok := roles[name]; ok {
+			role, err = ParseRole(roleStr)
+			if err != nil {
+				return nil, fmt.Errorf("team %s: %w", name, err)
+			}
+		}
+
+		teamTree, err := teamtree.Find(ctx, t.Tupelo(), teamDids[name])
+		if err != nil {
+			return nil, err
+		}
+
+		teams[i] = &Team{Name: name, Role: role, Tree: teamTree}
+	}
+
+	return teams, nil
+}
+
+// RoleFor returns the highest role granted to the given key address
+// by any team of the repo, or RoleNone if the address isn't on a team
+func (t *RepoTree) RoleFor(ctx context.Context, addr string) (Role, error) {
+	teams, err := t.TeamsFor(ctx, addr)
+	if err != nil {
+		return RoleNone, err
+	}
+
+	role := RoleNone
+	for _, team := range teams {
+		if !role.Includes(team.Role) {
+			role = team.Role
+		}
+	}
+
+	log.Debugf("%s has role %s on %s", addr, role, t.Did())
+
+	return role, nil
+}
+
+// TeamsFor returns the teams of the repo that have a member owned by the
+// given key address, including members of nested teams
+func (t *RepoTree) TeamsFor(ctx context.Context, addr string) ([]*Team, error) {
+	m, err := t.membershipOf(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return m.teams, nil
+}
+
+// MemberFor returns the did of the first team member owned by the given
+// key address, or "" if there is none
+func (t *RepoTree) MemberFor(ctx context.Context, addr string) (string, error) {
+	m, err := t.membershipOf(ctx, addr)
+	if err != nil {
+		return "", err
+	}
+	return m.member, nil
+}
+
+// membership is where a key address is found in the repo's teams
+type membership struct {
+	// teams have a member owned by the address
+	teams []*Team
+	// member is the did of the first team member owned by the address
+	member string
+}
+
+// membershipOf resolves the expanded members of every team of the repo and
+// checks which of them addr owns, looking up each member tree once
+func (t *RepoTree) membershipOf(ctx context.Context, addr string) (*membership, error) {
+	teams, err := t.Teams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[string]bool)
+	m := &membership{teams: []*Team{}}
+
+	for _, team := range teams {
+		members, err := team.Tree.ExpandMembers(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, member := range members {
+			isOwner, ok := owners[member.Did()]
+			if !ok {
+				memberTree, err := tree.Find(ctx, t.Tupelo(), member.Did())
+				if err != nil {
+					return nil, err
+				}
+
+				isOwner, err = memberTree.IsOwner(ctx, addr)
+				if err != nil {
+					return nil, err
+				}
+				owners[member.Did()] = isOwner
+			}
+
+			if isOwner {
+				if m.member == "" {
+					m.member = member.Did()
+				}
+				m.teams = append(m.teams, team)
+				break
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// AddTeam creates a new team with the given role. The team chaintree is
+// managed by the repo's admins, its members don't own it.
+func (t *RepoTree) AddTeam(ctx context.Context, key *ecdsa.PrivateKey, name string, role Role, members teamtree.Members) (*Team, error) {
+	if err := t.requireAdmin(ctx, key); err != nil {
+		return nil, err
+	}
+
+	teams, err := t.Teams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, team := range teams {
+		if team.Name == name {
+			return nil, fmt.Errorf("team %s already exists", name)
+		}
+	}
+
+	admins, err := adminDids(ctx, teams)
+	if err != nil {
+		return nil, err
+	}
+
+	teamTree, err := teamtree.Create(ctx, &teamtree.Options{
+		Name:    t.Name() + " " + name + " team",
+		Tupelo:  t.Tupelo(),
+		Members: members,
+		Owners:  admins,
+		Managed: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	team := &Team{Name: name, Role: role, Tree: teamTree}
+
+	err = t.setTeams(ctx, key, append(teams, team), team)
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// SetTeamRole changes the role granted by an existing team
+func (t *RepoTree) SetTeamRole(ctx context.Context, key *ecdsa.PrivateKey, name string, role Role) error {
+	if err := t.requireAdmin(ctx, key); err != nil {
+		return err
+	}
+
+	teams, err := t.Teams(ctx)
+	if err != nil {
+		return err
+	}
+
+	var changed *Team
+	for _, team := range teams {
+		if team.Name == name {
+			changed = team
+		}
+	}
+	if changed == nil {
+		return teamtree.ErrNotFound
+	}
+	if changed.Role == role {
+		return nil
+	}
+
+	changed.Role = role
+
+	return t.setTeams(ctx, key, teams, changed)
+}
+
+// InviteTeamMembers invites members to the named team, they become members
+// once they accept with the returned codes and an admin adds them with
+// AddAcceptedInvitees
+func (t *RepoTree) InviteTeamMembers(ctx context.Context, key *ecdsa.PrivateKey, name string, members teamtree.Members) ([]*teamtree.InviteCode, error) {
+	if err := t.requireAdmin(ctx, key); err != nil {
+		return nil, err
+	}
+
+	team, err := t.Team(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := team.ListMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	invitedBy := crypto.PubkeyToAddress(key.PublicKey).String()
+
+	invitations := make([]*teamtree.Invitation, len(members))
+	codes := make([]*teamtree.InviteCode, len(members))
+	for i, member := range members {
+		if current.IsMember(member.Did()) {
+			return nil, fmt.Errorf("%s is already a member of the %s team", member.Name(), name)
+		}
+
+		invitations[i], err = teamtree.NewInvitation(member, invitedBy)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = invitations[i].Code(team.Did())
+	}
+
+	err = team.Invite(ctx, key, invitations)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// InviteAccepted returns true if the invitee accepted the invitation to the
+// team with did teamDid in their user tree, with a signature by a key which
+// owns that tree
+func (t *RepoTree) InviteAccepted(ctx context.Context, teamDid string, invitation *teamtree.Invitation) (bool, error) {
+	userTree, err := usertree.Find(ctx, invitation.Username, t.Tupelo())
+	if err == usertree.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if userTree.Did() != invitation.Did {
+		return false, nil
+	}
+
+	signature, err := userTree.InviteAcceptance(ctx, teamDid)
+	if err != nil || signature == nil {
+		return false, err
+	}
+
+	signer, err := invitation.Code(teamDid).Signer(signature)
+	if err != nil {
+		// an acceptance of an earlier invitation, or a bad signature
+		return false, nil
+	}
+
+	return userTree.IsOwner(ctx, signer)
+}
+
+// AddAcceptedInvitees adds the invitees of the named team which accepted
+// their invitations as members, and returns their invitations
+func (t *RepoTree) AddAcceptedInvitees(ctx context.Context, key *ecdsa.PrivateKey, name string) ([]*teamtree.Invitation, error) {
+	if err := t.requireAdmin(ctx, key); err != nil {
+		return nil, err
+	}
+
+	team, err := t.Team(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := team.Invitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accepted := []*teamtree.Invitation{}
+	for _, invitation := range invitations {
+		ok, err := t.InviteAccepted(ctx, team.Did(), invitation)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			accepted = append(accepted, invitation)
+		}
+	}
+
+	if len(accepted) == 0 {
+		return accepted, nil
+	}
+
+	err = team.AddInvitees(ctx, key, accepted)
+	if err != nil {
+		return nil, err
+	}
+
+	return accepted, t.updateOwners(ctx, key)
+}
+
+// AddTeamMemberTeams includes other teams in the named team, granting their
+// members the team's role
+func (t *RepoTree) AddTeamMemberTeams(ctx context.Context, key *ecdsa.PrivateKey, name string, teams teamtree.Members) error {
+	if err := t.requireAdmin(ctx, key); err != nil {
+		return err
+	}
+
+	team, err := t.Team(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	err = team.AddMemberTeams(ctx, key, teams)
+	if err != nil {
+		return err
+	}
+
+	return t.updateOwners(ctx, key)
+}
+
+// PlanTeamRemoval works out removing usernames from the named team, either
+// as members or pending invitees, without notarizing anything. It fails if
+// the repo would be left without any admin.
+func (t *RepoTree) PlanTeamRemoval(ctx context.Context, name string, usernames []string) (*teamtree.Removal, error) {
+	teams, err := t.Teams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var team *Team
+	for _, tm := range teams {
+		if tm.Name == name {
+			team = tm
+		}
+	}
+	if team == nil {
+		return nil, teamtree.ErrNotFound
+	}
+
+	removal, err := team.Tree.PlanRemoval(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	if !team.Role.CanAdmin() || len(removal.Members) > 0 {
+		return removal, nil
+	}
+
+	for _, memberTeam := range removal.Teams {
+		nested, err := teamtree.Find(ctx, t.Tupelo(), memberTeam.Did())
+		if err != nil {
+			return nil, err
+		}
+
+		members, err := nested.ExpandMembers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(members) > 0 {
+			return removal, nil
+		}
+	}
+
+	for _, other := range teams {
+		if other == team || !other.Role.CanAdmin() {
+			continue
+		}
+
+		members, err := other.Tree.ExpandMembers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(members) > 0 {
+			return removal, nil
+		}
+	}
+
+	return nil, ErrLastAdmin
+}
+
+// RemoveTeamMembers removes usernames from the named team, revoking the
+// invitations of those who haven't accepted yet
+func (t *RepoTree) RemoveTeamMembers(ctx context.Context, key *ecdsa.PrivateKey, name string, usernames []string) (*teamtree.Removal, error) {
+	if err := t.requireAdmin(ctx, key); err != nil {
+		return nil, err
+	}
+
+	removal, err := t.PlanTeamRemoval(ctx, name, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := t.Team(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = team.Remove(ctx, key, removal)
+	if err != nil {
+		return nil, err
+	}
+
+	return removal, t.updateOwners(ctx, key)
+}
+
+func (t *RepoTree) requireAdmin(ctx context.Context, key *ecdsa.PrivateKey) error {
+	role, err := t.RoleFor(ctx, crypto.PubkeyToAddress(key.PublicKey).String())
+	if err != nil {
+		return err
+	}
+	if !role.CanAdmin() {
+		return ErrNotAdmin
+	}
+	return nil
+}
+
+// setTeams writes the changed team and then updates the owners of the
+// repo's chaintrees, see setOwners
+func (t *RepoTree) setTeams(ctx context.Context, key *ecdsa.PrivateKey, teams []*Team, changed *Team) error {
+	hasAdmin := false
+	for _, team := range teams {
+		hasAdmin = hasAdmin || team.Role.CanAdmin()
+	}
+	if !hasAdmin {
+		return ErrLastAdminTeam
+	}
+
+	teamTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(teamsMapPath, changed.Name), "/"), changed.Tree.Did())
+	if err != nil {
+		return err
+	}
+
+	roleTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(rolesMapPath, changed.Name), "/"), string(changed.Role))
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, []*transactions.Transaction{teamTxn, roleTxn})
+	if err != nil {
+		return err
+	}
+
+	return t.setOwners(ctx, key, teams)
+}
+
+// updateOwners updates the owners of the repo's chaintrees after the
+// members of a team changed
+func (t *RepoTree) updateOwners(ctx context.Context, key *ecdsa.PrivateKey) error {
+	teams, err := t.Teams(ctx)
+	if err != nil {
+		return err
+	}
+	return t.setOwners(ctx, key, teams)
+}
+
+// setOwners makes the repo's admins the only owners of every team chaintree
+// of the repo, so that members can't change their own teams, and the
+// members with write access the owners of the repo chaintree. Chaintrees
+// already owned that way are left alone.
+func (t *RepoTree) setOwners(ctx context.Context, key *ecdsa.PrivateKey, teams []*Team) error {
+	admins, err := adminDids(ctx, teams)
+	if err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		managed, err := team.Tree.IsManaged(ctx)
+		if err != nil {
+			return err
+		}
+		current, err := team.Tree.ChainTree().Authentications()
+		if err != nil {
+			return err
+		}
+		if managed && sameOwners(current, admins) {
+			continue
+		}
+
+		if err := team.Tree.SetManagers(ctx, key, admins); err != nil {
+			return fmt.Errorf("error updating the owners of the %s team: %w", team.Name, err)
+		}
+	}
+
+	owners, err := writerDids(ctx, teams)
+	if err != nil {
+		return err
+	}
+
+	current, err := t.ChainTree().Authentications()
+	if err != nil {
+		return err
+	}
+	if sameOwners(current, owners) {
+		return nil
+	}
+
+	ownershipTxn, err := chaintree.NewSetOwnershipTransaction(owners)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, []*transactions.Transaction{ownershipTxn})
+	return err
+}
+
+// adminDids returns the dids of the users on the admin teams, including
+// through nested teams. They are users rather than the teams themselves,
+// since Tupelo refuses to resolve owners in a loop, which admin teams
+// owning each other would be.
+func adminDids(ctx context.Context, teams []*Team) ([]string, error) {
+	dids := []string{}
+	for _, team := range teams {
+		if !team.Role.CanAdmin() {
+			continue
+		}
+
+		members, err := team.Tree.ExpandMembers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		dids = append(dids, members.Dids()...)
+	}
+
+	dids = uniqueSorted(dids)
+	if len(dids) == 0 {
+		return nil, ErrLastAdmin
+	}
+	return dids, nil
+}
+
+// writerDids returns the owners of the repo chaintree: the members of every
+// team with write access and their nested teams, so that members later
+// added to a nested team can write right away, along with the members of
+// the nested teams, whose chaintrees may be managed by admins elsewhere
+func writerDids(ctx context.Context, teams []*Team) ([]string, error) {
+	dids := []string{}
+	for _, team := range teams {
+		if !team.Role.CanWrite() {
+			continue
+		}
+
+		members, err := team.Tree.ListMembers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		memberTeams, err := team.Tree.ListMemberTeams(ctx)
+		if err != nil {
+			return nil, err
+		}
+		expanded, err := team.Tree.ExpandMembers(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		dids = append(dids, members.Dids()...)
+		dids = append(dids, memberTeams.Dids()...)
+		dids = append(dids, expanded.Dids()...)
+	}
+	return uniqueSorted(dids), nil
+}
+
+func uniqueSorted(dids []string) []string {
+	seen := make(map[string]bool, len(dids))
+	unique := []string{}
+	for _, did := range dids {
+		if !seen[did] {
+			seen[did] = true
+			unique = append(unique, did)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+func sameOwners(a, b []string) bool {
+	a, b = uniqueSorted(a), uniqueSorted(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *RepoTree) stringMap(ctx context.Context, mapPath []string) (map[string]string, error) {
+	path := append([]string{"tree", "data"}, mapPath...)
+	valMap := make(map[string]string)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return valMap, nil
+	}
+
+	valMapUncast, ok := valUncast.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
+	}
+
+	for k, v := range valMapUncast {
+		if v == nil {
+			continue
+		}
+		vstr, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("key %s at path %v is %T, expected string", k, path, v)
+		}
+		valMap[k] = vstr
+	}
+
+	return valMap, nil
+}
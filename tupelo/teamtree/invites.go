@@ -0,0 +1,290 @@
+package teamtree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+// Invitations are pending members of a team. An invitee accepts by signing
+// the invitation with a key of their own user tree and recording the
+// signature there, and a team admin adds them once the signature checks
+// out. No key to the team tree is handed out, and repo access only follows
+// the members map.
+var invitesPath = []string{"invites"}
+
+// inviteSalt keeps invitation signatures from being valid for anything else
+const inviteSalt = "decentragit-invite-v0"
+
+var (
+	ErrNoInvitation      = errors.New("no pending invitation")
+	ErrInvalidInviteCode = errors.New("invalid invite code")
+	ErrInvalidAcceptance = errors.New("invalid invitation acceptance")
+)
+
+const inviteIDLength = 16
+
+type Invitation struct {
+	Username string
+	Did      string
+	// ID tells invitations of the same user apart, so that accepting an
+	// earlier invitation doesn't accept a later one
+	ID string
+	// InvitedBy is the address of the key which sent the invitation
+	InvitedBy string
+	CreatedAt time.Time
+}
+
+// NewInvitation creates an invitation for member
+func NewInvitation(member MemberIface, invitedBy string) (*Invitation, error) {
+	id := make([]byte, inviteIDLength)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &Invitation{
+		Username:  member.Name(),
+		Did:       member.Did(),
+		ID:        hexutil.Encode(id),
+		InvitedBy: invitedBy,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Code returns the code sent to the invitee of the team with did teamDid
+func (i *Invitation) Code(teamDid string) *InviteCode {
+	return &InviteCode{
+		Team:     teamDid,
+		Username: i.Username,
+		ID:       i.ID,
+	}
+}
+
+func (i *Invitation) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"did":       i.Did,
+		"id":        i.ID,
+		"invitedBy": i.InvitedBy,
+		"createdAt": i.CreatedAt.Unix(),
+	}
+}
+
+func invitationFromMap(username string, m map[string]interface{}) (*Invitation, error) {
+	i := &Invitation{Username: username}
+
+	for key, dst := range map[string]*string{
+		"did":       &i.Did,
+		"id":        &i.ID,
+		"invitedBy": &i.InvitedBy,
+	} {
+		val, ok := m[key].(string)
+		if !ok {
+			return nil, fmt.Errorf("invitation of %s %s is %T, expected string", username, key, m[key])
+		}
+		*dst = val
+	}
+
+	switch val := m["createdAt"].(type) {
+	case int64:
+		i.CreatedAt = time.Unix(val, 0)
+	case uint64:
+		i.CreatedAt = time.Unix(int64(val), 0)
+	case int:
+		i.CreatedAt = time.Unix(int64(val), 0)
+	}
+
+	return i, nil
+}
+
+func invitePath(username string) string {
+	return strings.Join(append(append([]string{}, invitesPath...), username), "/")
+}
+
+// Invitations returns the pending invitations of the team sorted by
+// username
+func (t *TeamTree) Invitations(ctx context.Context) ([]*Invitation, error) {
+	path := append([]string{"tree", "data"}, invitesPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return nil, nil
+	}
+
+	invites, ok := valUncast.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
+	}
+
+	usernames := make([]string, 0, len(invites))
+	for username := range invites {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	invitations := []*Invitation{}
+	for _, username := range usernames {
+		// removed invitations are set to nil
+		if invites[username] == nil {
+			continue
+		}
+
+		// each invitation is stored as a linked node
+		inviteUncast, _, err := t.Resolve(ctx, append(append([]string{}, path...), username))
+		if err != nil {
+			return nil, err
+		}
+
+		inviteMap, ok := inviteUncast.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invitation of %s is %T, expected map", username, inviteUncast)
+		}
+
+		invitation, err := invitationFromMap(username, inviteMap)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
+
+// Invitation returns the pending invitation of username
+func (t *TeamTree) Invitation(ctx context.Context, username string) (*Invitation, error) {
+	invitations, err := t.Invitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, invitation := range invitations {
+		if invitation.Username == username {
+			return invitation, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w for %s to %s", ErrNoInvitation, username, t.Name())
+}
+
+// Invite records the invitations, replacing existing invitations of the
+// same users
+func (t *TeamTree) Invite(ctx context.Context, key *ecdsa.PrivateKey, invitations []*Invitation) error {
+	txns := []*transactions.Transaction{}
+	for _, invitation := range invitations {
+		txn, err := chaintree.NewSetDataTransaction(invitePath(invitation.Username), invitation.toMap())
+		if err != nil {
+			return err
+		}
+		txns = append(txns, txn)
+	}
+
+	_, err := t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, txns)
+	return err
+}
+
+// AddInvitees adds the invitees of invitations as members and clears the
+// invitations, in a single block. Their acceptances must be verified first.
+func (t *TeamTree) AddInvitees(ctx context.Context, key *ecdsa.PrivateKey, invitations []*Invitation) error {
+	members, err := t.ListMembers(ctx)
+	if err != nil {
+		return err
+	}
+
+	teams, err := t.ListMemberTeams(ctx)
+	if err != nil {
+		return err
+	}
+
+	inviteTxns := []*transactions.Transaction{}
+	for _, invitation := range invitations {
+		if !members.IsMember(invitation.Did) {
+			members = append(members, NewMember(invitation.Did, invitation.Username))
+		}
+
+		txn, err := chaintree.NewSetDataTransaction(invitePath(invitation.Username), nil)
+		if err != nil {
+			return err
+		}
+		inviteTxns = append(inviteTxns, txn)
+	}
+
+	txns, err := t.memberOwnershipTxns(ctx, members, teams)
+	if err != nil {
+		return err
+	}
+
+	membersTxn, err := chaintree.NewSetDataTransaction(strings.Join(membersPath, "/"), members.Map())
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, append(append(txns, membersTxn), inviteTxns...))
+	return err
+}
+
+// InviteCode is sent to an invitee so that they can accept an invitation.
+// It identifies the invitation and holds no secret.
+type InviteCode struct {
+	Team     string
+	Username string
+	ID       string
+}
+
+func (c *InviteCode) String() string {
+	return tree.EncodeCode(c.Team, c.Username, c.ID)
+}
+
+// digest is what the invitee signs to accept the invitation
+func (c *InviteCode) digest() []byte {
+	return crypto.Keccak256([]byte(strings.Join([]string{inviteSalt, c.Team, c.Username, c.ID}, "\n")))
+}
+
+// Sign signs the acceptance of the invitation with key, which must be an
+// owner of the invitee's user tree
+func (c *InviteCode) Sign(key *ecdsa.PrivateKey) ([]byte, error) {
+	return crypto.Sign(c.digest(), key)
+}
+
+// Signer returns the address of the key which signed the acceptance of the
+// invitation
+func (c *InviteCode) Signer(signature []byte) (string, error) {
+	pub, err := crypto.SigToPub(c.digest(), signature)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidAcceptance, err)
+	}
+	return crypto.PubkeyToAddress(*pub).String(), nil
+}
+
+// ParseInviteCode decodes a code from InviteCode.String
+func ParseInviteCode(code string) (*InviteCode, error) {
+	parts, err := tree.DecodeCode(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInviteCode, err)
+	}
+	if len(parts) != 3 {
+		return nil, ErrInvalidInviteCode
+	}
+
+	if id, err := hexutil.Decode(parts[2]); err != nil || len(id) != inviteIDLength {
+		return nil, ErrInvalidInviteCode
+	}
+
+	return &InviteCode{
+		Team:     parts[0],
+		Username: parts[1],
+		ID:       parts[2],
+	}, nil
+}
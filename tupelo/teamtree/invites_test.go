@@ -0,0 +1,65 @@
+package teamtree
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInviteCode(t *testing.T) {
+	invitation, err := NewInvitation(NewMember("did:tupelo:0x1234", "alice"), "0xabcd")
+	require.Nil(t, err)
+
+	code := invitation.Code("did:tupelo:0x5678")
+
+	parsed, err := ParseInviteCode(strings.ToLower(code.String()))
+	require.Nil(t, err)
+	require.Equal(t, code, parsed)
+
+	_, err = ParseInviteCode(code.String()[1:])
+	require.True(t, errors.Is(err, ErrInvalidInviteCode))
+}
+
+func TestInviteAcceptance(t *testing.T) {
+	invitation, err := NewInvitation(NewMember("did:tupelo:0x1234", "alice"), "0xabcd")
+	require.Nil(t, err)
+	code := invitation.Code("did:tupelo:0x5678")
+
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+
+	signature, err := code.Sign(key)
+	require.Nil(t, err)
+
+	signer, err := code.Signer(signature)
+	require.Nil(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey).String(), signer)
+
+	// the signature doesn't accept a later invitation of the same user
+	reinvitation, err := NewInvitation(NewMember("did:tupelo:0x1234", "alice"), "0xabcd")
+	require.Nil(t, err)
+	signer, err = reinvitation.Code("did:tupelo:0x5678").Signer(signature)
+	require.Nil(t, err)
+	require.NotEqual(t, crypto.PubkeyToAddress(key.PublicKey).String(), signer)
+
+	_, err = code.Signer([]byte("not a signature"))
+	require.True(t, errors.Is(err, ErrInvalidAcceptance))
+}
+
+func TestInvitationMap(t *testing.T) {
+	invitation, err := NewInvitation(NewMember("did:tupelo:0x1234", "alice"), "0xabcd")
+	require.Nil(t, err)
+
+	decoded, err := invitationFromMap("alice", invitation.toMap())
+	require.Nil(t, err)
+	require.Equal(t, invitation.Did, decoded.Did)
+	require.Equal(t, invitation.ID, decoded.ID)
+	require.Equal(t, invitation.InvitedBy, decoded.InvitedBy)
+	require.Equal(t, invitation.CreatedAt.Unix(), decoded.CreatedAt.Unix())
+
+	_, err = invitationFromMap("alice", map[string]interface{}{"did": "did:tupelo:0x1234"})
+	require.NotNil(t, err)
+}
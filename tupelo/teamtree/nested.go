@@ -0,0 +1,196 @@
+package teamtree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/quorumcontrol/chaintree/chaintree"
+)
+
+// Teams can include other teams, such as an org's team, by did. A member
+// team's did is an owner of the team tree, so its members own it through
+// their own trees, and adding someone to the member team gives them access
+// everywhere it is included.
+var memberTeamsPath = []string{"teams"}
+
+// maxTeamDepth bounds how deep nested teams are expanded
+const maxTeamDepth = 8
+
+var ErrTeamCycle = errors.New("team can not include itself")
+
+// findTeam looks up nested teams, replaced by tests which build team trees
+// in memory
+var findTeam = Find
+
+// ListMemberTeams returns the teams included in this team sorted by name
+func (t *TeamTree) ListMemberTeams(ctx context.Context) (Members, error) {
+	path := append([]string{"tree", "data"}, memberTeamsPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return Members{}, nil
+	}
+
+	valMapUncast, ok := valUncast.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
+	}
+
+	names := make([]string, 0, len(valMapUncast))
+	for name := range valMapUncast {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	teams := make(Members, len(names))
+	for i, name := range names {
+		did, ok := valMapUncast[name].(string)
+		if !ok {
+			return nil, fmt.Errorf("key %s at path %v is %T, expected string", name, path, valMapUncast[name])
+		}
+		teams[i] = NewMember(did, name)
+	}
+	return teams, nil
+}
+
+// AddMemberTeams includes teams in this team
+func (t *TeamTree) AddMemberTeams(ctx context.Context, key *ecdsa.PrivateKey, teams Members) error {
+	current, err := t.ListMemberTeams(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		if team.Did() == t.Did() {
+			return ErrTeamCycle
+		}
+
+		// including a team which already includes this one would let each
+		// grant the other's access forever
+		memberTeam, err := findTeam(ctx, t.Tupelo(), team.Did())
+		if err != nil {
+			return err
+		}
+		includes, err := memberTeam.Includes(ctx, t.Did())
+		if err != nil {
+			return err
+		}
+		if includes {
+			return fmt.Errorf("%w: %s already includes this team", ErrTeamCycle, team.Name())
+		}
+
+		if !current.IsMember(team.Did()) {
+			current = append(current, team)
+		}
+	}
+
+	members, err := t.ListMembers(ctx)
+	if err != nil {
+		return err
+	}
+
+	txns, err := t.memberOwnershipTxns(ctx, members, current)
+	if err != nil {
+		return err
+	}
+
+	teamsTxn, err := chaintree.NewSetDataTransaction(strings.Join(memberTeamsPath, "/"), current.Map())
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, append(txns, teamsTxn))
+	return err
+}
+
+// Includes returns true if the team with the given did is this team or is
+// nested anywhere inside it
+func (t *TeamTree) Includes(ctx context.Context, did string) (bool, error) {
+	found := false
+	err := t.walk(ctx, make(map[string]bool), 0, func(team *TeamTree) error {
+		found = found || team.Did() == did
+		return nil
+	})
+	return found, err
+}
+
+// Nested returns this team and every team nested in it, each once
+func (t *TeamTree) Nested(ctx context.Context) ([]*TeamTree, error) {
+	teams := []*TeamTree{}
+	err := t.walk(ctx, make(map[string]bool), 0, func(team *TeamTree) error {
+		teams = append(teams, team)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// ExpandMembers returns the user members of this team and of all teams
+// nested in it, each once, sorted by name
+func (t *TeamTree) ExpandMembers(ctx context.Context) (Members, error) {
+	members := Members{}
+	err := t.walk(ctx, make(map[string]bool), 0, func(team *TeamTree) error {
+		teamMembers, err := team.ListMembers(ctx)
+		if err != nil {
+			return err
+		}
+		for _, member := range teamMembers {
+			if !members.IsMember(member.Did()) {
+				members = append(members, member)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Name() < members[j].Name()
+	})
+
+	return members, nil
+}
+
+// walk calls fn with this team and every team nested in it, skipping
+// teams already visited
+func (t *TeamTree) walk(ctx context.Context, visited map[string]bool, depth int, fn func(*TeamTree) error) error {
+	if visited[t.Did()] {
+		return nil
+	}
+	visited[t.Did()] = true
+
+	if depth > maxTeamDepth {
+		return fmt.Errorf("teams are nested more than %d deep at %s", maxTeamDepth, t.Name())
+	}
+
+	if err := fn(t); err != nil {
+		return err
+	}
+
+	memberTeams, err := t.ListMemberTeams(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, memberTeam := range memberTeams {
+		team, err := findTeam(ctx, t.Tupelo(), memberTeam.Did())
+		if err != nil {
+			return fmt.Errorf("error finding team %s: %w", memberTeam.Name(), err)
+		}
+
+		if err := team.walk(ctx, visited, depth+1, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
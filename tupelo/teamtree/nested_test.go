@@ -0,0 +1,106 @@
+package teamtree
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tupelo "github.com/quorumcontrol/tupelo/sdk/gossip/client"
+	"github.com/stretchr/testify/require"
+)
+
+// useTeams lets nested teams be found among the given in-memory teams
+func useTeams(t *testing.T, teams ...*TeamTree) {
+	byDid := make(map[string]*TeamTree, len(teams))
+	for _, team := range teams {
+		byDid[team.Did()] = team
+	}
+
+	findTeam = func(ctx context.Context, _ *tupelo.Client, did string) (*TeamTree, error) {
+		team, ok := byDid[did]
+		if !ok {
+			return nil, ErrNotFound
+		}
+		return team, nil
+	}
+	t.Cleanup(func() {
+		findTeam = Find
+	})
+}
+
+func TestExpandMembersOfNestedTeams(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inner, _ := testTeam(t, ctx, Members{
+		NewMember("did:tupelo:dave", "dave"),
+		NewMember("did:tupelo:alice", "alice"),
+	}, Members{})
+	middle, _ := testTeam(t, ctx, Members{
+		NewMember("did:tupelo:bob", "bob"),
+	}, Members{NewMember(inner.Did(), "@inner")})
+	outer, _ := testTeam(t, ctx, Members{
+		NewMember("did:tupelo:alice", "alice"),
+	}, Members{NewMember(middle.Did(), "@middle"), NewMember(inner.Did(), "@inner")})
+	useTeams(t, inner, middle, outer)
+
+	expanded, err := outer.ExpandMembers(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []string{"alice", "bob", "dave"}, expanded.Names())
+
+	nested, err := outer.Nested(ctx)
+	require.Nil(t, err)
+	require.Len(t, nested, 3)
+
+	includes, err := outer.Includes(ctx, inner.Did())
+	require.Nil(t, err)
+	require.True(t, includes)
+
+	includes, err = inner.Includes(ctx, outer.Did())
+	require.Nil(t, err)
+	require.False(t, includes)
+}
+
+func TestAddMemberTeamsRefusesCycles(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inner, _ := testTeam(t, ctx, Members{NewMember("did:tupelo:alice", "alice")}, Members{})
+	middle, _ := testTeam(t, ctx, Members{}, Members{NewMember(inner.Did(), "@inner")})
+	outer, _ := testTeam(t, ctx, Members{}, Members{NewMember(middle.Did(), "@middle")})
+	useTeams(t, inner, middle, outer)
+
+	err := inner.AddMemberTeams(ctx, nil, Members{NewMember(inner.Did(), "@inner")})
+	require.True(t, errors.Is(err, ErrTeamCycle))
+
+	err = inner.AddMemberTeams(ctx, nil, Members{NewMember(outer.Did(), "@outer")})
+	require.True(t, errors.Is(err, ErrTeamCycle))
+
+	teams, err := inner.ListMemberTeams(ctx)
+	require.Nil(t, err)
+	require.Empty(t, teams)
+}
+
+func TestExpandMembersDepthLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	team, _ := testTeam(t, ctx, Members{NewMember("did:tupelo:alice", "alice")}, Members{})
+	teams := []*TeamTree{team}
+	for i := 0; i < maxTeamDepth; i++ {
+		team, _ = testTeam(t, ctx, Members{}, Members{NewMember(team.Did(), "@nested")})
+		teams = append(teams, team)
+	}
+	useTeams(t, teams...)
+
+	expanded, err := team.ExpandMembers(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []string{"alice"}, expanded.Names())
+
+	team, _ = testTeam(t, ctx, Members{}, Members{NewMember(team.Did(), "@nested")})
+	useTeams(t, append(teams, team)...)
+
+	_, err = team.ExpandMembers(ctx)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "nested more than")
+}
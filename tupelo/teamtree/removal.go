@@ -0,0 +1,168 @@
+package teamtree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+)
+
+var (
+	ErrNotMember = errors.New("not a member or invitee of the team")
+	ErrNoOwners  = errors.New("team would be left without owners")
+)
+
+// Removal describes the team after removing members and revoking
+// invitations, so it can be previewed before it is notarized
+type Removal struct {
+	Removed      Members
+	RemovedTeams Members
+	Revoked      []*Invitation
+	// Members are the remaining members
+	Members Members
+	// Teams are the remaining member teams
+	Teams Members
+	// Owners are the owners of the team chaintree after the removal, which
+	// don't change for a managed team
+	Owners []string
+}
+
+// PlanRemoval works out the removal of names, each of which must be a
+// member, a member team or have a pending invitation
+func (t *TeamTree) PlanRemoval(ctx context.Context, usernames []string) (*Removal, error) {
+	current, err := t.ListMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	currentTeams, err := t.ListMemberTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := t.Invitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	removal := &Removal{}
+	removedOwners := make(map[string]bool)
+
+	for _, username := range usernames {
+		var member MemberIface
+		for _, m := range current {
+			if m.Name() == username {
+				member = m
+			}
+		}
+		if member != nil {
+			removal.Removed = append(removal.Removed, member)
+			removedOwners[member.Did()] = true
+			continue
+		}
+
+		var team MemberIface
+		for _, m := range currentTeams {
+			if m.Name() == username {
+				team = m
+			}
+		}
+		if team != nil {
+			removal.RemovedTeams = append(removal.RemovedTeams, team)
+			removedOwners[team.Did()] = true
+			continue
+		}
+
+		var invitation *Invitation
+		for _, i := range invitations {
+			if i.Username == username {
+				invitation = i
+			}
+		}
+		if invitation != nil {
+			removal.Revoked = append(removal.Revoked, invitation)
+			continue
+		}
+
+		return nil, fmt.Errorf("%s: %w", username, ErrNotMember)
+	}
+
+	removal.Members = Members{}
+	for _, member := range current {
+		if !removal.Removed.IsMember(member.Did()) {
+			removal.Members = append(removal.Members, member)
+		}
+	}
+
+	removal.Teams = Members{}
+	for _, team := range currentTeams {
+		if !removal.RemovedTeams.IsMember(team.Did()) {
+			removal.Teams = append(removal.Teams, team)
+		}
+	}
+
+	auths, err := t.ChainTree().Authentications()
+	if err != nil {
+		return nil, err
+	}
+
+	managed, err := t.IsManaged(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	removal.Owners = []string{}
+	for _, auth := range auths {
+		if managed {
+			removal.Owners = append(removal.Owners, auth)
+			continue
+		}
+		// a member removed under one name may still be a member under
+		// another
+		if !removedOwners[auth] || removal.Members.IsMember(auth) || removal.Teams.IsMember(auth) {
+			removal.Owners = append(removal.Owners, auth)
+		}
+	}
+
+	if len(removal.Owners) == 0 {
+		return nil, ErrNoOwners
+	}
+
+	return removal, nil
+}
+
+// Remove notarizes a removal from PlanRemoval in a single block
+func (t *TeamTree) Remove(ctx context.Context, key *ecdsa.PrivateKey, removal *Removal) error {
+	ownershipTxn, err := chaintree.NewSetOwnershipTransaction(removal.Owners)
+	if err != nil {
+		return err
+	}
+
+	membersTxn, err := chaintree.NewSetDataTransaction(strings.Join(membersPath, "/"), removal.Members.Map())
+	if err != nil {
+		return err
+	}
+
+	txns := []*transactions.Transaction{ownershipTxn, membersTxn}
+	if len(removal.RemovedTeams) > 0 {
+		teamsTxn, err := chaintree.NewSetDataTransaction(strings.Join(memberTeamsPath, "/"), removal.Teams.Map())
+		if err != nil {
+			return err
+		}
+		txns = append(txns, teamsTxn)
+	}
+	for _, invitation := range removal.Revoked {
+		txn, err := chaintree.NewSetDataTransaction(invitePath(invitation.Username), nil)
+		if err != nil {
+			return err
+		}
+		txns = append(txns, txn)
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, txns)
+	return err
+}
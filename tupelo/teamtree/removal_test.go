@@ -0,0 +1,101 @@
+package teamtree
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/chaintree/nodestore"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+	"github.com/quorumcontrol/tupelo/sdk/consensus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+// testTeam creates a team tree in memory with the given members and member
+// teams, an invitation for carol and an additional admin team owner
+func testTeam(t *testing.T, ctx context.Context, members Members, teams Members) (*TeamTree, *Invitation) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+
+	chainTree, err := consensus.NewSignedChainTree(ctx, key.PublicKey, nodestore.MustMemoryStore(ctx))
+	require.Nil(t, err)
+
+	invitation, err := NewInvitation(NewMember("did:tupelo:carol", "carol"), "0xabcd")
+	require.Nil(t, err)
+
+	owners := append(append(members.Dids(), teams.Dids()...), "did:tupelo:adminteam")
+	ownershipTxn, err := chaintree.NewSetOwnershipTransaction(owners)
+	require.Nil(t, err)
+	teamsTxn, err := chaintree.NewSetDataTransaction("teams", teams.Map())
+	require.Nil(t, err)
+	membersTxn, err := chaintree.NewSetDataTransaction("members", members.Map())
+	require.Nil(t, err)
+	inviteTxn, err := chaintree.NewSetDataTransaction(invitePath("carol"), invitation.toMap())
+	require.Nil(t, err)
+
+	block, err := consensus.SignBlock(ctx, &chaintree.BlockWithHeaders{
+		Block: chaintree.Block{
+			Transactions: []*transactions.Transaction{ownershipTxn, membersTxn, teamsTxn, inviteTxn},
+		},
+	}, key)
+	require.Nil(t, err)
+
+	valid, err := chainTree.ChainTree.ProcessBlock(ctx, block)
+	require.Nil(t, err)
+	require.True(t, valid)
+
+	return &TeamTree{tree.New("test team", chainTree, nil)}, invitation
+}
+
+func TestListMembersIsSorted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	team, _ := testTeam(t, ctx, Members{
+		NewMember("did:tupelo:dave", "dave"),
+		NewMember("did:tupelo:alice", "alice"),
+		NewMember("did:tupelo:bob", "bob"),
+	}, Members{})
+
+	members, err := team.ListMembers(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []string{"alice", "bob", "dave"}, members.Names())
+
+	expanded, err := team.ExpandMembers(ctx)
+	require.Nil(t, err)
+	require.Equal(t, members.Names(), expanded.Names())
+}
+
+func TestPlanRemoval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	team, invitation := testTeam(t, ctx, Members{
+		NewMember("did:tupelo:alice", "alice"),
+		NewMember("did:tupelo:bob", "bob"),
+	}, Members{
+		NewMember("did:tupelo:orgteam", "@org"),
+	})
+
+	removal, err := team.PlanRemoval(ctx, []string{"bob", "carol"})
+	require.Nil(t, err)
+	require.Equal(t, []string{"bob"}, removal.Removed.Names())
+	require.Len(t, removal.Revoked, 1)
+	require.Equal(t, invitation.ID, removal.Revoked[0].ID)
+	require.Equal(t, []string{"alice"}, removal.Members.Names())
+	require.Equal(t, []string{"@org"}, removal.Teams.Names())
+	require.ElementsMatch(t, []string{"did:tupelo:alice", "did:tupelo:orgteam", "did:tupelo:adminteam"}, removal.Owners)
+
+	removal, err = team.PlanRemoval(ctx, []string{"@org"})
+	require.Nil(t, err)
+	require.Equal(t, []string{"@org"}, removal.RemovedTeams.Names())
+	require.Empty(t, removal.Teams)
+	require.NotContains(t, removal.Owners, "did:tupelo:orgteam")
+
+	_, err = team.PlanRemoval(ctx, []string{"mallory"})
+	require.True(t, errors.Is(err, ErrNotMember))
+}
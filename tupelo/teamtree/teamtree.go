@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"fmt"
+	"sort"
 	"strings"
 
 	logging "github.com/ipfs/go-log"
@@ -19,10 +20,22 @@ var ErrNotFound = tree.ErrNotFound
 
 var membersPath = []string{"members"}
 
+// managedPath marks a team whose chaintree is owned by the users managing
+// it, such as the admins of a repo, rather than by its members
+var managedPath = []string{"managed"}
+
 type Options struct {
 	Name    string
 	Tupelo  *tupelo.Client
 	Members Members
+	// Teams are teams included in the team, see AddMemberTeams
+	Teams Members
+	// Owners are additional owners of the team chaintree beyond its members,
+	// or its only owners when Managed
+	Owners []string
+	// Managed teams are owned by Owners alone, so that their members can't
+	// change them
+	Managed bool
 }
 
 type TeamTree struct {
@@ -44,11 +57,34 @@ func Create(ctx context.Context, opts *Options) (*TeamTree, error) {
 		return nil, err
 	}
 
+	txns := []*transactions.Transaction{membersTxn}
+	if len(opts.Teams) > 0 {
+		teamsTxn, err := chaintree.NewSetDataTransaction(strings.Join(memberTeamsPath, "/"), opts.Teams.Map())
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, teamsTxn)
+	}
+
+	owners := append(append(opts.Members.Dids(), opts.Teams.Dids()...), opts.Owners...)
+	if opts.Managed {
+		if len(opts.Owners) == 0 {
+			return nil, fmt.Errorf("managed team %s needs owners", opts.Name)
+		}
+		owners = opts.Owners
+
+		managedTxn, err := chaintree.NewSetDataTransaction(strings.Join(managedPath, "/"), true)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, managedTxn)
+	}
+
 	t, err := tree.Create(ctx, &tree.Options{
 		Name:           opts.Name,
 		Tupelo:         opts.Tupelo,
-		Owners:         opts.Members.Dids(),
-		AdditionalTxns: []*transactions.Transaction{membersTxn},
+		Owners:         owners,
+		AdditionalTxns: txns,
 	})
 	if err != nil {
 		return nil, err
@@ -66,6 +102,7 @@ func (t *TeamTree) AddMembers(ctx context.Context, key *ecdsa.PrivateKey, member
 	return t.SetMembers(ctx, key, append(currentMembers, members...))
 }
 
+// ListMembers returns the members of the team sorted by name
 func (t *TeamTree) ListMembers(ctx context.Context) (Members, error) {
 	path := append([]string{"tree", "data"}, membersPath...)
 	valUncast, _, err := t.Resolve(ctx, path)
@@ -81,47 +118,121 @@ func (t *TeamTree) ListMembers(ctx context.Context) (Members, error) {
 		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
 	}
 
-	members := make(Members, len(valMapUncast))
-	i := 0
+	names := make([]string, 0, len(valMapUncast))
+	for name := range valMapUncast {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	for name, v := range valMapUncast {
-		did, ok := v.(string)
+	members := make(Members, len(names))
+	for i, name := range names {
+		did, ok := valMapUncast[name].(string)
 		if !ok {
-			return nil, fmt.Errorf("key %s at path %v is %T, expected string", name, path, v)
+			return nil, fmt.Errorf("key %s at path %v is %T, expected string", name, path, valMapUncast[name])
 		}
 		members[i] = NewMember(did, name)
-		i++
 	}
 	return members, nil
 }
 
-func (t *TeamTree) RemoveMembers(ctx context.Context, key *ecdsa.PrivateKey, membersToRemove Members) error {
-	currentMembers, err := t.ListMembers(ctx)
+// SetMembers replaces the members of the team. Owners of the team chaintree
+// that aren't members (see Options.Owners) are retained.
+func (t *TeamTree) SetMembers(ctx context.Context, key *ecdsa.PrivateKey, members Members) error {
+	teams, err := t.ListMemberTeams(ctx)
 	if err != nil {
 		return err
 	}
 
-	members := Members{}
-	for _, member := range currentMembers {
-		if !membersToRemove.IsMember(member.Did()) {
-			members = append(members, member)
-		}
+	txns, err := t.memberOwnershipTxns(ctx, members, teams)
+	if err != nil {
+		return err
 	}
 
-	return t.SetMembers(ctx, key, members)
+	membersTxn, err := chaintree.NewSetDataTransaction(strings.Join(membersPath, "/"), members.Map())
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, append(txns, membersTxn))
+	return err
 }
 
-func (t *TeamTree) SetMembers(ctx context.Context, key *ecdsa.PrivateKey, members Members) error {
-	ownershipTxn, err := chaintree.NewSetOwnershipTransaction(members.Dids())
+// IsManaged returns true if the team chaintree is owned by its managers
+// rather than its members
+func (t *TeamTree) IsManaged(ctx context.Context) (bool, error) {
+	path := append([]string{"tree", "data"}, managedPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	managed, _ := valUncast.(bool)
+	return managed, nil
+}
+
+// SetManagers makes owners the only owners of the team chaintree, which no
+// longer changes along with its members
+func (t *TeamTree) SetManagers(ctx context.Context, key *ecdsa.PrivateKey, owners []string) error {
+	if len(owners) == 0 {
+		return ErrNoOwners
+	}
+
+	ownershipTxn, err := chaintree.NewSetOwnershipTransaction(owners)
 	if err != nil {
 		return err
 	}
 
-	membersTxn, err := chaintree.NewSetDataTransaction(strings.Join(membersPath, "/"), members.Map())
+	managedTxn, err := chaintree.NewSetDataTransaction(strings.Join(managedPath, "/"), true)
 	if err != nil {
 		return err
 	}
 
-	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, []*transactions.Transaction{ownershipTxn, membersTxn})
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), key, []*transactions.Transaction{ownershipTxn, managedTxn})
 	return err
 }
+
+// memberOwnershipTxns makes members and teams the owners of an unmanaged
+// team chaintree along with its owners which are neither. Managed teams
+// keep their owners.
+func (t *TeamTree) memberOwnershipTxns(ctx context.Context, members Members, teams Members) ([]*transactions.Transaction, error) {
+	managed, err := t.IsManaged(ctx)
+	if err != nil || managed {
+		return nil, err
+	}
+
+	owners, err := t.nonMemberOwners(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ownershipTxn, err := chaintree.NewSetOwnershipTransaction(append(append(members.Dids(), teams.Dids()...), owners...))
+	if err != nil {
+		return nil, err
+	}
+
+	return []*transactions.Transaction{ownershipTxn}, nil
+}
+
+func (t *TeamTree) nonMemberOwners(ctx context.Context) ([]string, error) {
+	currentMembers, err := t.ListMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	currentTeams, err := t.ListMemberTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	auths, err := t.ChainTree().Authentications()
+	if err != nil {
+		return nil, err
+	}
+
+	owners := []string{}
+	for _, auth := range auths {
+		if !currentMembers.IsMember(auth) && !currentTeams.IsMember(auth) {
+			owners = append(owners, auth)
+		}
+	}
+	return owners, nil
+}
@@ -0,0 +1,51 @@
+package tree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"fmt"
+	"strings"
+)
+
+const codeChecksumLength = 4
+
+const codeGroupLength = 5
+
+var codeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EncodeCode joins parts into a checksummed code which is easy to read
+// out or type on another machine
+func EncodeCode(parts ...string) string {
+	payload := []byte(strings.Join(parts, "\n"))
+	checksum := sha256.Sum256(payload)
+	encoded := codeEncoding.EncodeToString(append(payload, checksum[:codeChecksumLength]...))
+
+	groups := []string{}
+	for len(encoded) > codeGroupLength {
+		groups = append(groups, encoded[:codeGroupLength])
+		encoded = encoded[codeGroupLength:]
+	}
+	groups = append(groups, encoded)
+
+	return strings.Join(groups, "-")
+}
+
+// DecodeCode returns the parts of a code from EncodeCode, ignoring case,
+// whitespace and dashes
+func DecodeCode(code string) ([]string, error) {
+	code = strings.ToUpper(strings.Join(strings.Fields(strings.ReplaceAll(code, "-", " ")), ""))
+
+	decoded, err := codeEncoding.DecodeString(code)
+	if err != nil || len(decoded) <= codeChecksumLength {
+		return nil, fmt.Errorf("not a code")
+	}
+
+	payload := decoded[:len(decoded)-codeChecksumLength]
+	checksum := sha256.Sum256(payload)
+	if !bytes.Equal(checksum[:codeChecksumLength], decoded[len(payload):]) {
+		return nil, fmt.Errorf("checksum mismatch, check for typos")
+	}
+
+	return strings.Split(string(payload), "\n"), nil
+}
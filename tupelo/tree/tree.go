@@ -7,12 +7,15 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	logging "github.com/ipfs/go-log"
 	"github.com/quorumcontrol/chaintree/chaintree"
 	"github.com/quorumcontrol/messages/v2/build/go/transactions"
 	"github.com/quorumcontrol/tupelo/sdk/consensus"
 	"github.com/quorumcontrol/tupelo/sdk/gossip/client"
 )
 
+var log = logging.Logger("decentragit.tree")
+
 var ErrNotFound = client.ErrNotFound
 
 type Tree struct {
@@ -49,6 +52,22 @@ func (t *Tree) Resolve(ctx context.Context, path []string) (interface{}, []strin
 	return t.ChainTree().ChainTree.Dag.Resolve(ctx, path)
 }
 
+func (t *Tree) IsOwner(ctx context.Context, addr string) (bool, error) {
+	auths, err := t.ChainTree().Authentications()
+	if err != nil {
+		return false, err
+	}
+	log.Debugf("checking %s is owner of %s, chaintree auths: %v", addr, t.Did(), auths)
+
+	for _, auth := range auths {
+		if auth == addr {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func Find(ctx context.Context, tupelo *client.Client, did string) (*Tree, error) {
 	chainTree, err := tupelo.GetLatest(ctx, did)
 	if err == client.ErrNotFound {
@@ -0,0 +1,146 @@
+package usertree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+)
+
+var devicesMapPath = []string{"devices"}
+
+var ErrDeviceNotFound = errors.New("device not found")
+
+// Device is an additional machine authorized to act as the user
+type Device struct {
+	Name    string
+	Address string
+}
+
+func ValidateDeviceName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/\n") {
+		return fmt.Errorf("invalid device name %q", name)
+	}
+	return nil
+}
+
+// Devices returns the authorized devices of the user sorted by name
+func (t *UserTree) Devices(ctx context.Context) ([]*Device, error) {
+	path := append([]string{"tree", "data"}, devicesMapPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return []*Device{}, nil
+	}
+
+	valMap, ok := valUncast.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
+	}
+
+	devices := []*Device{}
+	for name, addrUncast := range valMap {
+		if addrUncast == nil {
+			continue
+		}
+		addr, ok := addrUncast.(string)
+		if !ok {
+			return nil, fmt.Errorf("device %s is %T, expected address string", name, addrUncast)
+		}
+		devices = append(devices, &Device{Name: name, Address: addr})
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Name < devices[j].Name
+	})
+
+	return devices, nil
+}
+
+// AddDevice adds the device address to the owners of the user tree
+func (t *UserTree) AddDevice(ctx context.Context, ownerKey *ecdsa.PrivateKey, device *Device) error {
+	if err := ValidateDeviceName(device.Name); err != nil {
+		return err
+	}
+
+	devices, err := t.Devices(ctx)
+	if err != nil {
+		return err
+	}
+	for _, existing := range devices {
+		if existing.Name == device.Name {
+			return fmt.Errorf("a device named %s already exists", device.Name)
+		}
+	}
+
+	owners, err := t.ChainTree().Authentications()
+	if err != nil {
+		return err
+	}
+
+	for _, owner := range owners {
+		if owner == device.Address {
+			return fmt.Errorf("%s is already an owner of %s", device.Address, t.Name())
+		}
+	}
+
+	return t.setDevice(ctx, ownerKey, device.Name, device.Address, append(owners, device.Address))
+}
+
+// RevokeDevice removes the named device from the owners of the user tree.
+// Other devices and the recovery phrase key are left untouched.
+func (t *UserTree) RevokeDevice(ctx context.Context, ownerKey *ecdsa.PrivateKey, name string) (*Device, error) {
+	devices, err := t.Devices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var revoked *Device
+	for _, device := range devices {
+		if device.Name == name {
+			revoked = device
+		}
+	}
+	if revoked == nil {
+		return nil, ErrDeviceNotFound
+	}
+
+	owners, err := t.ChainTree().Authentications()
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := []string{}
+	for _, owner := range owners {
+		if owner != revoked.Address {
+			remaining = append(remaining, owner)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil, fmt.Errorf("can not revoke %s, it is the only owner of %s", name, t.Name())
+	}
+
+	return revoked, t.setDevice(ctx, ownerKey, name, nil, remaining)
+}
+
+func (t *UserTree) setDevice(ctx context.Context, ownerKey *ecdsa.PrivateKey, name string, addr interface{}, owners []string) error {
+	ownershipTxn, err := chaintree.NewSetOwnershipTransaction(owners)
+	if err != nil {
+		return err
+	}
+
+	deviceTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(devicesMapPath, name), "/"), addr)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), ownerKey, []*transactions.Transaction{ownershipTxn, deviceTxn})
+	return err
+}
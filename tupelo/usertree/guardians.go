@@ -0,0 +1,317 @@
+package usertree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+// A user's guardians each hold an encrypted share of a recovery key. The
+// recovery key is a standing owner of the user tree for as long as the
+// guardians are set, since Tupelo accepts a block signed by any single
+// owner and can't require several guardians to sign. Nobody holds the
+// whole key: recovering the user takes a threshold of guardians
+// re-encrypting their share to a new key of the user in their own tree,
+// so every approval is notarized by the guardian, and the recovering
+// machine combining the shares. Setting guardians again, which key rotate
+// does, replaces the recovery key.
+var (
+	guardiansPath         = []string{"guardians"}
+	guardianPublicKeyPath = []string{"guardian", "publicKey"}
+	guardianApprovalsPath = []string{"guardian", "approvals"}
+)
+
+var (
+	ErrGuardianNotEnabled  = errors.New("user has not enabled being a guardian")
+	ErrNoGuardians         = errors.New("user has no guardians")
+	ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+)
+
+// Guardian is a user which can help recover another user
+type Guardian struct {
+	Username string
+	Did      string
+	// Share is this guardian's share of the recovery key, encrypted to
+	// their guardian key
+	Share []byte
+}
+
+// Guardians is the recovery configuration of a user
+type Guardians struct {
+	Threshold int
+	// Address is the recovery key's address, an owner of the user tree
+	Address   string
+	Guardians []*Guardian
+}
+
+func (g *Guardians) Find(username string) *Guardian {
+	for _, guardian := range g.Guardians {
+		if guardian.Username == username {
+			return guardian
+		}
+	}
+	return nil
+}
+
+// Guardians returns the user's guardians, or ErrNoGuardians if none are set
+func (t *UserTree) Guardians(ctx context.Context) (*Guardians, error) {
+	path := append([]string{"tree", "data"}, guardiansPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return nil, ErrNoGuardians
+	}
+
+	valMap, ok := valUncast.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
+	}
+
+	g := &Guardians{Guardians: []*Guardian{}}
+
+	switch threshold := valMap["threshold"].(type) {
+	case int:
+		g.Threshold = threshold
+	case int64:
+		g.Threshold = int(threshold)
+	case uint64:
+		g.Threshold = int(threshold)
+	default:
+		return nil, fmt.Errorf("guardian threshold is %T, expected int", threshold)
+	}
+
+	g.Address, ok = valMap["address"].(string)
+	if !ok {
+		return nil, fmt.Errorf("guardian recovery address is %T, expected string", valMap["address"])
+	}
+
+	members, ok := valMap["members"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("guardian members are %T, expected map", valMap["members"])
+	}
+
+	for username, memberUncast := range members {
+		member, ok := memberUncast.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("guardian %s is %T, expected map", username, memberUncast)
+		}
+		did, _ := member["did"].(string)
+		share, err := hexutil.Decode(fmt.Sprint(member["share"]))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding share of guardian %s: %w", username, err)
+		}
+		g.Guardians = append(g.Guardians, &Guardian{Username: username, Did: did, Share: share})
+	}
+
+	sort.Slice(g.Guardians, func(i, j int) bool {
+		return g.Guardians[i].Username < g.Guardians[j].Username
+	})
+
+	return g, nil
+}
+
+// SetGuardians replaces the user's guardians and recovery key address. The
+// previous recovery key, if any, is no longer an owner afterwards.
+func (t *UserTree) SetGuardians(ctx context.Context, ownerKey *ecdsa.PrivateKey, g *Guardians) error {
+	if g.Threshold < 1 || g.Threshold > len(g.Guardians) {
+		return fmt.Errorf("threshold must be between 1 and the number of guardians (%d)", len(g.Guardians))
+	}
+
+	owners, err := t.ChainTree().Authentications()
+	if err != nil {
+		return err
+	}
+
+	previous, err := t.Guardians(ctx)
+	if err != nil && err != ErrNoGuardians {
+		return err
+	}
+
+	newOwners := []string{}
+	for _, owner := range owners {
+		if previous != nil && owner == previous.Address {
+			continue
+		}
+		newOwners = append(newOwners, owner)
+	}
+	newOwners = append(newOwners, g.Address)
+
+	members := make(map[string]interface{}, len(g.Guardians))
+	for _, guardian := range g.Guardians {
+		if guardian.Username == t.Name() {
+			return fmt.Errorf("%s can not be their own guardian", t.Name())
+		}
+		members[guardian.Username] = map[string]interface{}{
+			"did":   guardian.Did,
+			"share": hexutil.Encode(guardian.Share),
+		}
+	}
+
+	ownershipTxn, err := chaintree.NewSetOwnershipTransaction(newOwners)
+	if err != nil {
+		return err
+	}
+
+	guardiansTxn, err := chaintree.NewSetDataTransaction(strings.Join(guardiansPath, "/"), map[string]interface{}{
+		"threshold": g.Threshold,
+		"address":   g.Address,
+		"members":   members,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), ownerKey, []*transactions.Transaction{ownershipTxn, guardiansTxn})
+	return err
+}
+
+// Recover makes newAddr the only owner of the user tree, signed by the
+// recovery key. All devices and the guardians are removed, since the
+// recovery key is known to the recovering machine afterwards.
+func (t *UserTree) Recover(ctx context.Context, recoveryKey *ecdsa.PrivateKey, newAddr string) error {
+	g, err := t.Guardians(ctx)
+	if err != nil {
+		return err
+	}
+
+	if addr := crypto.PubkeyToAddress(recoveryKey.PublicKey).String(); addr != g.Address {
+		return fmt.Errorf("recovery key %s does not match the guardian recovery address %s", addr, g.Address)
+	}
+
+	ownershipTxn, err := chaintree.NewSetOwnershipTransaction([]string{newAddr})
+	if err != nil {
+		return err
+	}
+
+	guardiansTxn, err := chaintree.NewSetDataTransaction(strings.Join(guardiansPath, "/"), nil)
+	if err != nil {
+		return err
+	}
+
+	devicesTxn, err := chaintree.NewSetDataTransaction(strings.Join(devicesMapPath, "/"), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), recoveryKey, []*transactions.Transaction{ownershipTxn, guardiansTxn, devicesTxn})
+	return err
+}
+
+// GuardianPublicKey returns the key shares for this user as a guardian are
+// encrypted to, or ErrGuardianNotEnabled
+func (t *UserTree) GuardianPublicKey(ctx context.Context) (*ecdsa.PublicKey, error) {
+	path := append([]string{"tree", "data"}, guardianPublicKeyPath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return nil, ErrGuardianNotEnabled
+	}
+
+	encoded, ok := valUncast.(string)
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected string", path, valUncast)
+	}
+
+	pubBytes, err := hexutil.Decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.DecompressPubkey(pubBytes)
+}
+
+// SetGuardianPublicKey publishes the key other users encrypt their
+// recovery key shares to when choosing this user as a guardian
+func (t *UserTree) SetGuardianPublicKey(ctx context.Context, ownerKey *ecdsa.PrivateKey, pub *ecdsa.PublicKey) error {
+	txn, err := chaintree.NewSetDataTransaction(strings.Join(guardianPublicKeyPath, "/"), hexutil.Encode(crypto.CompressPubkey(pub)))
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), ownerKey, []*transactions.Transaction{txn})
+	return err
+}
+
+// RecoveryApproval returns the share this user approved for the recovery of
+// username, encrypted to the recovering key, or nil if there is none
+func (t *UserTree) RecoveryApproval(ctx context.Context, username string) ([]byte, error) {
+	path := append(append([]string{"tree", "data"}, guardianApprovalsPath...), username)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return nil, nil
+	}
+
+	encoded, ok := valUncast.(string)
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected string", path, valUncast)
+	}
+
+	return hexutil.Decode(encoded)
+}
+
+// ApproveRecovery records this user's approval of the recovery of username
+// as the share re-encrypted to the recovering key
+func (t *UserTree) ApproveRecovery(ctx context.Context, ownerKey *ecdsa.PrivateKey, username string, share []byte) error {
+	path := append(append([]string{}, guardianApprovalsPath...), username)
+	txn, err := chaintree.NewSetDataTransaction(strings.Join(path, "/"), hexutil.Encode(share))
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), ownerKey, []*transactions.Transaction{txn})
+	return err
+}
+
+// RecoveryCode is shown by a user recovering their account, so their
+// guardians can approve moving it to the new key
+type RecoveryCode struct {
+	Username  string
+	PublicKey *ecdsa.PublicKey
+}
+
+func (c *RecoveryCode) String() string {
+	return tree.EncodeCode(c.Username, hexutil.Encode(crypto.CompressPubkey(c.PublicKey)))
+}
+
+// Address is the address the user is recovered to
+func (c *RecoveryCode) Address() string {
+	return crypto.PubkeyToAddress(*c.PublicKey).String()
+}
+
+func ParseRecoveryCode(code string) (*RecoveryCode, error) {
+	parts, err := tree.DecodeCode(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecoveryCode, err)
+	}
+	if len(parts) != 2 {
+		return nil, ErrInvalidRecoveryCode
+	}
+
+	pubBytes, err := hexutil.Decode(parts[1])
+	if err != nil {
+		return nil, ErrInvalidRecoveryCode
+	}
+	pub, err := crypto.DecompressPubkey(pubBytes)
+	if err != nil {
+		return nil, ErrInvalidRecoveryCode
+	}
+
+	return &RecoveryCode{Username: parts[0], PublicKey: pub}, nil
+}
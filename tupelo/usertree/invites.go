@@ -0,0 +1,50 @@
+package usertree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+)
+
+// A user accepts a team invitation in their own tree, with their signature
+// of the invitation keyed by the team's did, so the acceptance is notarized
+// by the invitee and can be checked by the team's admins
+var acceptedInvitesPath = []string{"invites", "accepted"}
+
+// InviteAcceptance returns the signature with which this user accepted an
+// invitation to the team with did teamDid, or nil if there is none
+func (t *UserTree) InviteAcceptance(ctx context.Context, teamDid string) ([]byte, error) {
+	path := append(append([]string{"tree", "data"}, acceptedInvitesPath...), teamDid)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return nil, nil
+	}
+
+	encoded, ok := valUncast.(string)
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected string", path, valUncast)
+	}
+
+	return hexutil.Decode(encoded)
+}
+
+// AcceptInvite records this user's signature accepting an invitation to the
+// team with did teamDid, replacing any earlier acceptance
+func (t *UserTree) AcceptInvite(ctx context.Context, ownerKey *ecdsa.PrivateKey, teamDid string, signature []byte) error {
+	path := append(append([]string{}, acceptedInvitesPath...), teamDid)
+	txn, err := chaintree.NewSetDataTransaction(strings.Join(path, "/"), hexutil.Encode(signature))
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), ownerKey, []*transactions.Transaction{txn})
+	return err
+}
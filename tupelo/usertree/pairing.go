@@ -0,0 +1,43 @@
+package usertree
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/quorumcontrol/dgit/tupelo/tree"
+)
+
+var ErrInvalidPairingCode = errors.New("invalid pairing code")
+
+// PairingCode is shown on a new device so that an already authorized
+// device can add its key to the user's owners
+type PairingCode struct {
+	Username string
+	Device   string
+	Address  string
+}
+
+func (c *PairingCode) String() string {
+	return tree.EncodeCode(c.Username, c.Device, c.Address)
+}
+
+// ParsePairingCode decodes a code from PairingCode.String, ignoring case,
+// whitespace and dashes
+func ParsePairingCode(code string) (*PairingCode, error) {
+	parts, err := tree.DecodeCode(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPairingCode, err)
+	}
+
+	if len(parts) != 3 || !common.IsHexAddress(parts[2]) {
+		return nil, ErrInvalidPairingCode
+	}
+
+	return &PairingCode{
+		Username: parts[0],
+		Device:   parts[1],
+		Address:  common.HexToAddress(parts[2]).String(),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package usertree
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPairingCode(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+
+	code := &PairingCode{
+		Username: "alice",
+		Device:   "work-laptop",
+		Address:  crypto.PubkeyToAddress(key.PublicKey).String(),
+	}
+
+	parsed, err := ParsePairingCode(strings.ToLower(code.String()))
+	require.Nil(t, err)
+	require.Equal(t, code, parsed)
+
+	typo := []byte(code.String())
+	if typo[0] == 'A' {
+		typo[0] = 'B'
+	} else {
+		typo[0] = 'A'
+	}
+	_, err = ParsePairingCode(string(typo))
+	require.True(t, errors.Is(err, ErrInvalidPairingCode))
+
+	_, err = ParsePairingCode("not a code")
+	require.NotNil(t, err)
+}
+
+func TestRecoveryCode(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+
+	code := &RecoveryCode{
+		Username:  "alice",
+		PublicKey: &key.PublicKey,
+	}
+
+	parsed, err := ParseRecoveryCode(code.String())
+	require.Nil(t, err)
+	require.Equal(t, code.Username, parsed.Username)
+	require.Equal(t, code.Address(), parsed.Address())
+
+	pairing := &PairingCode{Username: "alice", Device: "laptop", Address: code.Address()}
+	_, err = ParseRecoveryCode(pairing.String())
+	require.True(t, errors.Is(err, ErrInvalidRecoveryCode))
+}
@@ -0,0 +1,191 @@
+package usertree
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+)
+
+var profilePath = []string{"profile"}
+
+// Profile is the public information a user shares about themselves
+type Profile struct {
+	DisplayName string
+	Email       string
+	// Avatar is a link to an image
+	Avatar  string
+	Website string
+	// GPGKeys are ASCII armored public keys
+	GPGKeys []string
+	// SSHKeys are public keys in authorized_keys format
+	SSHKeys []string
+}
+
+func (p *Profile) IsEmpty() bool {
+	return p.DisplayName == "" && p.Email == "" && p.Avatar == "" && p.Website == "" && len(p.GPGKeys) == 0 && len(p.SSHKeys) == 0
+}
+
+// Validate checks the email, links and public keys are well formed
+func (p *Profile) Validate() error {
+	if p.Email != "" {
+		if _, err := mail.ParseAddress(p.Email); err != nil {
+			return fmt.Errorf("invalid email %q: %w", p.Email, err)
+		}
+	}
+
+	for name, link := range map[string]string{"avatar": p.Avatar, "website": p.Website} {
+		if link == "" {
+			continue
+		}
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %s link %q, must be an http(s) url", name, link)
+		}
+	}
+
+	for _, key := range p.GPGKeys {
+		if _, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key)); err != nil {
+			return fmt.Errorf("invalid GPG public key: %w", err)
+		}
+	}
+
+	for _, key := range p.SSHKeys {
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key)); err != nil {
+			return fmt.Errorf("invalid SSH public key: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GPGKeyIDs returns the fingerprints of the profile's GPG keys
+func (p *Profile) GPGKeyIDs() []string {
+	ids := []string{}
+	for _, key := range p.GPGKeys {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+		if err != nil {
+			continue
+		}
+		for _, entity := range entities {
+			ids = append(ids, fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint))
+		}
+	}
+	return ids
+}
+
+// SSHKeyFingerprints returns the SHA256 fingerprints of the profile's SSH
+// keys
+func (p *Profile) SSHKeyFingerprints() []string {
+	fingerprints := []string{}
+	for _, key := range p.SSHKeys {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+		if err != nil {
+			continue
+		}
+		fingerprints = append(fingerprints, ssh.FingerprintSHA256(pub))
+	}
+	return fingerprints
+}
+
+// GPGKeyRing returns all of the profile's GPG keys as one key ring
+func (p *Profile) GPGKeyRing() (openpgp.EntityList, error) {
+	var armored bytes.Buffer
+	for _, key := range p.GPGKeys {
+		armored.WriteString(key)
+		armored.WriteString("\n")
+	}
+	return openpgp.ReadArmoredKeyRing(&armored)
+}
+
+func (p *Profile) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"displayName": p.DisplayName,
+		"email":       p.Email,
+		"avatar":      p.Avatar,
+		"website":     p.Website,
+		"gpgKeys":     p.GPGKeys,
+		"sshKeys":     p.SSHKeys,
+	}
+}
+
+func profileFromMap(m map[string]interface{}) (*Profile, error) {
+	profile := &Profile{}
+	profile.DisplayName, _ = m["displayName"].(string)
+	profile.Email, _ = m["email"].(string)
+	profile.Avatar, _ = m["avatar"].(string)
+	profile.Website, _ = m["website"].(string)
+
+	var err error
+	profile.GPGKeys, err = toStringSlice(m["gpgKeys"])
+	if err != nil {
+		return nil, fmt.Errorf("profile gpg keys: %w", err)
+	}
+	profile.SSHKeys, err = toStringSlice(m["sshKeys"])
+	if err != nil {
+		return nil, fmt.Errorf("profile ssh keys: %w", err)
+	}
+
+	return profile, nil
+}
+
+func toStringSlice(valUncast interface{}) ([]string, error) {
+	if valUncast == nil {
+		return nil, nil
+	}
+	vals, ok := valUncast.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("is %T, expected list", valUncast)
+	}
+	strs := make([]string, len(vals))
+	for i, v := range vals {
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("item %d is %T, expected string", i, v)
+		}
+		strs[i] = str
+	}
+	return strs, nil
+}
+
+// Profile returns the user's profile, which is empty if it was never set
+func (t *UserTree) Profile(ctx context.Context) (*Profile, error) {
+	path := append([]string{"tree", "data"}, profilePath...)
+	valUncast, _, err := t.Resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if valUncast == nil {
+		return &Profile{}, nil
+	}
+
+	valMap, ok := valUncast.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %v is %T, expected map", path, valUncast)
+	}
+
+	return profileFromMap(valMap)
+}
+
+// SetProfile replaces the user's profile
+func (t *UserTree) SetProfile(ctx context.Context, ownerKey *ecdsa.PrivateKey, profile *Profile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+
+	txn, err := chaintree.NewSetDataTransaction(strings.Join(profilePath, "/"), profile.toMap())
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), ownerKey, []*transactions.Transaction{txn})
+	return err
+}
@@ -0,0 +1,65 @@
+package usertree
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/ssh"
+)
+
+func testGPGKey(t *testing.T) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity("Alice", "", "alice@example.com", nil)
+	require.Nil(t, err)
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	require.Nil(t, err)
+	require.Nil(t, entity.Serialize(w))
+	require.Nil(t, w.Close())
+
+	return entity, buf.String()
+}
+
+func TestProfile(t *testing.T) {
+	entity, gpgKey := testGPGKey(t)
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.Nil(t, err)
+
+	profile := &Profile{
+		DisplayName: "Alice",
+		Email:       "alice@example.com",
+		Avatar:      "https://example.com/alice.png",
+		Website:     "https://alice.example.com",
+		GPGKeys:     []string{gpgKey},
+		SSHKeys:     []string{string(ssh.MarshalAuthorizedKey(sshPub))},
+	}
+	require.Nil(t, profile.Validate())
+	require.Equal(t, []string{ssh.FingerprintSHA256(sshPub)}, profile.SSHKeyFingerprints())
+	require.Len(t, profile.GPGKeyIDs(), 1)
+
+	keyRing, err := profile.GPGKeyRing()
+	require.Nil(t, err)
+	require.Equal(t, entity.PrimaryKey.Fingerprint, keyRing[0].PrimaryKey.Fingerprint)
+
+	// values come back from the chaintree as generic lists
+	m := profile.toMap()
+	m["gpgKeys"] = []interface{}{gpgKey}
+	m["sshKeys"] = []interface{}{profile.SSHKeys[0]}
+	roundTripped, err := profileFromMap(m)
+	require.Nil(t, err)
+	require.Equal(t, profile, roundTripped)
+
+	require.NotNil(t, (&Profile{Email: "not an email"}).Validate())
+	require.NotNil(t, (&Profile{Avatar: "ftp://example.com/a.png"}).Validate())
+	require.NotNil(t, (&Profile{SSHKeys: []string{"ssh-ed25519 garbage"}}).Validate())
+	require.NotNil(t, (&Profile{GPGKeys: []string{"not a key"}}).Validate())
+	require.True(t, (&Profile{}).IsEmpty())
+}
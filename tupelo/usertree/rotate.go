@@ -0,0 +1,59 @@
+package usertree
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/quorumcontrol/chaintree/chaintree"
+	"github.com/quorumcontrol/messages/v2/build/go/transactions"
+)
+
+// RotateOwner replaces the address of ownerKey with newAddr in the owners
+// of the user tree, keeping every other owner. If ownerKey belongs to a
+// device, the device is updated to the new address as well.
+func (t *UserTree) RotateOwner(ctx context.Context, ownerKey *ecdsa.PrivateKey, newAddr string) error {
+	oldAddr := crypto.PubkeyToAddress(ownerKey.PublicKey).String()
+
+	owners, err := t.ChainTree().Authentications()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, owner := range owners {
+		if owner == oldAddr {
+			owners[i] = newAddr
+			replaced = true
+		}
+	}
+	if !replaced {
+		return fmt.Errorf("%s is not an owner of %s", oldAddr, t.Name())
+	}
+
+	ownershipTxn, err := chaintree.NewSetOwnershipTransaction(owners)
+	if err != nil {
+		return err
+	}
+	txns := []*transactions.Transaction{ownershipTxn}
+
+	devices, err := t.Devices(ctx)
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if device.Address != oldAddr {
+			continue
+		}
+		deviceTxn, err := chaintree.NewSetDataTransaction(strings.Join(append(devicesMapPath, device.Name), "/"), newAddr)
+		if err != nil {
+			return err
+		}
+		txns = append(txns, deviceTxn)
+	}
+
+	_, err = t.Tupelo().PlayTransactions(ctx, t.ChainTree(), ownerKey, txns)
+	return err
+}
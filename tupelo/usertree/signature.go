@@ -0,0 +1,153 @@
+package usertree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	pgperrors "golang.org/x/crypto/openpgp/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHSignaturePrefix starts every armored SSH signature
+const SSHSignaturePrefix = "-----BEGIN SSH SIGNATURE-----"
+
+const (
+	pgpSignaturePrefix = "-----BEGIN PGP SIGNATURE-----"
+	sshSignatureSuffix = "-----END SSH SIGNATURE-----"
+	// sshSignatureNamespace is the namespace git signs objects in
+	sshSignatureNamespace = "git"
+)
+
+var (
+	ErrUnsupportedSignature = errors.New("unsupported signature format")
+	ErrNoMatchingKey        = errors.New("signature was not made with a key of the user")
+)
+
+// IsSSHSignature reports whether the armored signature is an SSH signature,
+// as created by git with gpg.format=ssh
+func IsSSHSignature(signature string) bool {
+	return strings.HasPrefix(strings.TrimSpace(signature), SSHSignaturePrefix)
+}
+
+// VerifySignature checks the armored GPG or SSH signature of payload was
+// made with one of the profile's keys, and returns the key's id or
+// fingerprint
+func (p *Profile) VerifySignature(payload []byte, signature string) (string, error) {
+	signature = strings.TrimSpace(signature)
+
+	switch {
+	case strings.HasPrefix(signature, pgpSignaturePrefix):
+		if len(p.GPGKeys) == 0 {
+			return "", ErrNoMatchingKey
+		}
+		keyRing, err := p.GPGKeyRing()
+		if err != nil {
+			return "", err
+		}
+		entity, err := openpgp.CheckArmoredDetachedSignature(keyRing, bytes.NewReader(payload), strings.NewReader(signature))
+		if err != nil {
+			if err == pgperrors.ErrUnknownIssuer {
+				return "", ErrNoMatchingKey
+			}
+			return "", err
+		}
+		return fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint), nil
+	case strings.HasPrefix(signature, SSHSignaturePrefix):
+		return p.verifySSHSignature(payload, signature)
+	default:
+		return "", ErrUnsupportedSignature
+	}
+}
+
+// sshSignatureBlob is the PROTOCOL.sshsig signature format
+type sshSignatureBlob struct {
+	Magic         [6]byte
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is what an SSH signature actually signs
+type sshSignedData struct {
+	Magic         [6]byte
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Hash          []byte
+}
+
+func (p *Profile) verifySSHSignature(payload []byte, armored string) (string, error) {
+	body := strings.TrimSuffix(strings.TrimPrefix(armored, SSHSignaturePrefix), sshSignatureSuffix)
+	raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+
+	blob := &sshSignatureBlob{}
+	if err = ssh.Unmarshal(raw, blob); err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	if string(blob.Magic[:]) != "SSHSIG" || blob.Version != 1 {
+		return "", ErrUnsupportedSignature
+	}
+	if blob.Namespace != sshSignatureNamespace {
+		return "", fmt.Errorf("SSH signature is for namespace %q, expected %q", blob.Namespace, sshSignatureNamespace)
+	}
+
+	signer, err := ssh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid SSH signature key: %w", err)
+	}
+
+	found := false
+	for _, key := range p.SSHKeys {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+		if err == nil && bytes.Equal(pub.Marshal(), signer.Marshal()) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", ErrNoMatchingKey
+	}
+
+	var h hash.Hash
+	switch blob.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported SSH signature hash %q", blob.HashAlgorithm)
+	}
+	h.Write(payload)
+
+	signed := ssh.Marshal(&sshSignedData{
+		Magic:         blob.Magic,
+		Namespace:     blob.Namespace,
+		Reserved:      blob.Reserved,
+		HashAlgorithm: blob.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})
+
+	sig := &ssh.Signature{}
+	if err = ssh.Unmarshal(blob.Signature, sig); err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+
+	if err = signer.Verify(signed, sig); err != nil {
+		return "", err
+	}
+
+	return ssh.FingerprintSHA256(signer), nil
+}
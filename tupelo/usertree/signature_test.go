@@ -0,0 +1,62 @@
+package usertree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+)
+
+const testSSHKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHyd7ihE/FX7Ttp8gJjC54dHIKTwR/cDcGQ+dnhmnJIh alice@example.com"
+
+const testSSHPayload = `tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904
+author Alice <alice@example.com> 1600000000 +0000
+committer Alice <alice@example.com> 1600000000 +0000
+
+signed commit
+`
+
+// created with ssh-keygen -Y sign -n git
+const testSSHSignature = `-----BEGIN SSH SIGNATURE-----
+U1NIU0lHAAAAAQAAADMAAAALc3NoLWVkMjU1MTkAAAAgfJ3uKET8VftO2nyAmMLnh0cgpP
+BH9wNwZD52eGackiEAAAADZ2l0AAAAAAAAAAZzaGE1MTIAAABTAAAAC3NzaC1lZDI1NTE5
+AAAAQIicac/SSuUEUbtRBnds1kgQdhU49PYjVwz7P0c8kDjSc7kH7AtBf4uZ9UvAzVc1NI
+k4DIn9zBJqmca9UU+5Ywg=
+-----END SSH SIGNATURE-----
+`
+
+func TestVerifySSHSignature(t *testing.T) {
+	profile := &Profile{SSHKeys: []string{testSSHKey}}
+	require.True(t, IsSSHSignature(testSSHSignature))
+
+	fingerprint, err := profile.VerifySignature([]byte(testSSHPayload), testSSHSignature)
+	require.Nil(t, err)
+	require.Equal(t, profile.SSHKeyFingerprints()[0], fingerprint)
+
+	_, err = profile.VerifySignature([]byte(testSSHPayload+"tampered"), testSSHSignature)
+	require.NotNil(t, err)
+
+	_, err = (&Profile{}).VerifySignature([]byte(testSSHPayload), testSSHSignature)
+	require.Equal(t, ErrNoMatchingKey, err)
+}
+
+func TestVerifyGPGSignature(t *testing.T) {
+	entity, gpgKey := testGPGKey(t)
+	profile := &Profile{GPGKeys: []string{gpgKey}}
+
+	payload := []byte(testSSHPayload)
+	var signature bytes.Buffer
+	require.Nil(t, openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(payload), nil))
+
+	keyID, err := profile.VerifySignature(payload, signature.String())
+	require.Nil(t, err)
+	require.Equal(t, profile.GPGKeyIDs()[0], keyID)
+
+	_, otherKey := testGPGKey(t)
+	_, err = (&Profile{GPGKeys: []string{otherKey}}).VerifySignature(payload, signature.String())
+	require.Equal(t, ErrNoMatchingKey, err)
+
+	_, err = profile.VerifySignature(payload, "not a signature")
+	require.Equal(t, ErrUnsupportedSignature, err)
+}